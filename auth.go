@@ -0,0 +1,123 @@
+package sky
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// An Authenticator adds credentials to every request sent to the server,
+// including the header of event streams.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// A Refresher is an Authenticator whose credentials can be refreshed. When
+// the server rejects a request as unauthorized, the client refreshes the
+// credentials and retries the request, or replays a stream's events, once.
+type Refresher interface {
+	Authenticator
+	Refresh(ctx context.Context) error
+}
+
+// AuthenticatorFunc adapts a function, such as a custom request signer, to
+// the Authenticator interface.
+type AuthenticatorFunc func(req *http.Request) error
+
+type basicAuth struct {
+	username string
+	password string
+}
+
+type bearerToken struct {
+	token string
+}
+
+// A RotatingToken is a bearer token that is fetched on first use and fetched
+// again whenever the server rejects it.
+type RotatingToken struct {
+	fetch func(ctx context.Context) (string, error)
+	mutex sync.Mutex
+	token string
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// BasicAuth returns an authenticator that uses HTTP basic authentication.
+func BasicAuth(username string, password string) Authenticator {
+	return &basicAuth{username: username, password: password}
+}
+
+// BearerToken returns an authenticator that sends a static bearer token.
+func BearerToken(token string) Authenticator {
+	return &bearerToken{token: token}
+}
+
+// NewRotatingToken returns a bearer token authenticator that retrieves its
+// token from fetch.
+func NewRotatingToken(fetch func(ctx context.Context) (string, error)) *RotatingToken {
+	return &RotatingToken{fetch: fetch}
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// Calls the function.
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+func (a *basicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.username, a.password)
+	return nil
+}
+
+func (a *bearerToken) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+// Adds the current token to the request, fetching one if necessary.
+func (t *RotatingToken) Authenticate(req *http.Request) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.token == "" {
+		if err := t.refresh(req.Context()); err != nil {
+			return err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return nil
+}
+
+// Fetches a new token.
+func (t *RotatingToken) Refresh(ctx context.Context) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.refresh(ctx)
+}
+
+func (t *RotatingToken) refresh(ctx context.Context) error {
+	token, err := t.fetch(ctx)
+	if err != nil {
+		return err
+	} else if token == "" {
+		return errors.New("sky: Token source returned a blank token")
+	}
+	t.token = token
+	return nil
+}
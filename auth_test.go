@@ -0,0 +1,122 @@
+package sky
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+// newAuthServer starts a fake server that only accepts requests with a given
+// Authorization header.
+func newAuthServer(authorization *atomic.Value) *skytest.Server {
+	server := skytest.NewUnstartedServer()
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != authorization.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	return server
+}
+
+// Ensure that basic auth is applied to requests and streams.
+func TestBasicAuth(t *testing.T) {
+	var authorization atomic.Value
+	authorization.Store("Basic dXNlcjpwYXNz")
+	server := newAuthServer(&authorization)
+	defer server.Close()
+
+	client := NewClientEx(server.Host(), server.Port())
+	table := NewTable(testTableName, nil)
+	if err := client.CreateTable(table); !IsUnauthorized(err) {
+		t.Fatalf("Expected unauthorized error: %v", err)
+	}
+
+	client.SetAuthenticator(BasicAuth("user", "pass"))
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to create table: %v", err)
+	}
+	stream, err := table.Stream()
+	if err != nil {
+		t.Fatalf("Failed to create event stream: (%v)", err)
+	}
+	stream.AddEvent("xyz", NewEvent(time.Now(), nil))
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Closing stream failed: (%v)", err)
+	}
+	if events, err := table.GetEvents("xyz"); err != nil || len(events) != 1 {
+		t.Fatalf("Failed to get 1 event back: %d events, (%v)", len(events), err)
+	}
+}
+
+// Ensure that a rotating token is refreshed when it is rejected.
+func TestRotatingToken(t *testing.T) {
+	var authorization atomic.Value
+	authorization.Store("Bearer token-1")
+	server := newAuthServer(&authorization)
+	defer server.Close()
+
+	var fetches int32
+	token := NewRotatingToken(func(ctx context.Context) (string, error) {
+		return fmt.Sprintf("token-%d", atomic.AddInt32(&fetches, 1)), nil
+	})
+	client := NewClientEx(server.Host(), server.Port())
+	client.SetAuthenticator(token)
+
+	if _, err := client.GetTables(); err != nil || atomic.LoadInt32(&fetches) != 1 {
+		t.Fatalf("Expected request with first token: %d (%v)", atomic.LoadInt32(&fetches), err)
+	}
+
+	// Rotate the token on the server.
+	authorization.Store("Bearer token-2")
+	if _, err := client.GetTables(); err != nil || atomic.LoadInt32(&fetches) != 2 {
+		t.Fatalf("Expected request with refreshed token: %d (%v)", atomic.LoadInt32(&fetches), err)
+	}
+
+	// Only one refresh is attempted per request.
+	authorization.Store("Bearer other")
+	if _, err := client.GetTables(); !IsUnauthorized(err) || atomic.LoadInt32(&fetches) != 3 {
+		t.Fatalf("Expected unauthorized error: %d (%v)", atomic.LoadInt32(&fetches), err)
+	}
+}
+
+// Ensure that a stream without a reconnect policy refreshes a rejected token
+// and replays its events once.
+func TestRotatingTokenStream(t *testing.T) {
+	var authorization atomic.Value
+	authorization.Store("Bearer token-1")
+	server := newAuthServer(&authorization)
+	defer server.Close()
+
+	var fetches int32
+	client := NewClientEx(server.Host(), server.Port())
+	client.SetAuthenticator(NewRotatingToken(func(ctx context.Context) (string, error) {
+		return fmt.Sprintf("token-%d", atomic.AddInt32(&fetches, 1)), nil
+	}))
+	table := NewTable(testTableName, nil)
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to create table: %v", err)
+	}
+	stream, err := table.Stream()
+	if err != nil {
+		t.Fatalf("Failed to create event stream: (%v)", err)
+	}
+
+	// Rotate the token on the server after the stream has connected.
+	authorization.Store("Bearer token-2")
+	stream.AddEvent("xyz", NewEvent(time.Now(), nil))
+	if err := stream.Close(); err != nil || atomic.LoadInt32(&fetches) != 2 {
+		t.Fatalf("Expected stream with refreshed token: %d (%v)", atomic.LoadInt32(&fetches), err)
+	}
+	if events, err := table.GetEvents("xyz"); err != nil || len(events) != 1 {
+		t.Fatalf("Failed to get 1 event back: %d events, (%v)", len(events), err)
+	}
+}
@@ -0,0 +1,177 @@
+package sky
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// The default number of concurrent requests in a batch.
+const DefaultBatchConcurrency = 8
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// BatchOptions configures a batch fetch. Zero values use the defaults.
+type BatchOptions struct {
+	// The maximum number of concurrent requests. Defaults to
+	// DefaultBatchConcurrency.
+	Concurrency int
+
+	// Bounds the events fetched for each object. Nil fetches every event.
+	Events *EventOptions
+}
+
+// ObjectEvents is the result of fetching one object's events in a batch.
+type ObjectEvents struct {
+	ObjectId string
+	Events   []*Event
+	Err      error
+}
+
+// A BatchError is returned when some objects in a batch could not be
+// fetched. The events of the other objects are still returned.
+type BatchError struct {
+	Errors map[string]error
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// Retrieves the events of many objects concurrently.
+func (t *table) GetEventsBatch(ids []string) (map[string][]*Event, error) {
+	return t.GetEventsBatchContext(context.Background(), ids, nil)
+}
+
+// Retrieves the events of many objects concurrently using the given context.
+// The results are keyed by object id. If any objects fail then a *BatchError
+// is returned along with the results of the rest.
+func (t *table) GetEventsBatchContext(ctx context.Context, ids []string, options *BatchOptions) (map[string][]*Event, error) {
+	results := make(map[string][]*Event, len(ids))
+	errs := map[string]error{}
+	for result := range t.StreamEventsBatch(ctx, ids, options) {
+		if result.Err != nil {
+			errs[result.ObjectId] = result.Err
+		} else {
+			results[result.ObjectId] = result.Events
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	if len(errs) > 0 {
+		return results, &BatchError{Errors: errs}
+	}
+	return results, nil
+}
+
+// Fetches the events of many objects concurrently and sends each object's
+// result on the returned channel as it completes. The channel is closed once
+// every object is fetched or the context is done. Callers that stop reading
+// early must cancel the context.
+func (t *table) StreamEventsBatch(ctx context.Context, ids []string, options *BatchOptions) <-chan *ObjectEvents {
+	var opts BatchOptions
+	if options != nil {
+		opts = *options
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultBatchConcurrency
+	}
+
+	// Queue each object once.
+	queue := make(chan string, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			queue <- id
+		}
+	}
+	close(queue)
+	if opts.Concurrency > len(seen) {
+		opts.Concurrency = len(seen)
+	}
+
+	results := make(chan *ObjectEvents)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				if ctx.Err() != nil {
+					return
+				}
+				result := &ObjectEvents{ObjectId: id}
+				result.Events, result.Err = t.fetchEvents(ctx, id, opts.Events)
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// fetchEvents retrieves an object's events, within bounds if there are any.
+func (t *table) fetchEvents(ctx context.Context, objectId string, options *EventOptions) ([]*Event, error) {
+	if options == nil {
+		return t.GetEventsContext(ctx, objectId)
+	}
+	it, err := t.EventsContext(ctx, objectId, options)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	events := []*Event{}
+	for it.Next() {
+		events = append(events, it.Event())
+	}
+	return events, it.Err()
+}
+
+//--------------------------------------
+// Errors
+//--------------------------------------
+
+// The error message, which includes the first failed object's error.
+func (e *BatchError) Error() string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	if len(ids) == 0 {
+		return "sky.Batch: No objects failed"
+	}
+	return fmt.Sprintf("sky.Batch: %d objects failed: %s: %v", len(ids), ids[0], e.Errors[ids[0]])
+}
+
+// The errors of each failed object.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
@@ -0,0 +1,87 @@
+package sky
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+// Ensure that many objects are fetched concurrently within the limit and
+// that failures are reported per object.
+func TestGetEventsBatch(t *testing.T) {
+	var mutex sync.Mutex
+	var active, peak int
+	server := skytest.NewUnstartedServer()
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		if active++; active > peak {
+			peak = active
+		}
+		mutex.Unlock()
+		defer func() {
+			mutex.Lock()
+			active--
+			mutex.Unlock()
+		}()
+		if strings.Contains(r.URL.Path, "/objects/bad/") {
+			http.Error(w, `{"message":"broken"}`, http.StatusInternalServerError)
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+
+	client := NewClientEx(server.Host(), server.Port())
+	table := NewTable(testTableName, nil)
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to setup test table: %v", err)
+	}
+	now := time.Now()
+	var ids []string
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("user%d", i)
+		ids = append(ids, id)
+		for j := 0; j <= i%3; j++ {
+			table.AddEvent(id, NewEvent(now.Add(time.Duration(j)*time.Hour), nil), Replace)
+		}
+	}
+	mutex.Lock()
+	peak = 0
+	mutex.Unlock()
+
+	results, err := table.GetEventsBatchContext(context.Background(), append(ids, "bad", "user0"), &BatchOptions{Concurrency: 3})
+	batchErr, ok := err.(*BatchError)
+	if !ok || len(batchErr.Errors) != 1 || batchErr.Errors["bad"] == nil {
+		t.Fatalf("Expected error for bad object: %v", err)
+	}
+	if len(results) != 20 || len(results["user5"]) != 3 || len(results["user9"]) != 1 {
+		t.Fatalf("Unexpected results: %d objects", len(results))
+	}
+	mutex.Lock()
+	p := peak
+	mutex.Unlock()
+	if p > 3 || p < 2 {
+		t.Fatalf("Unexpected concurrency: %d", p)
+	}
+
+	// Results are streamed as they complete and can be bounded.
+	count := 0
+	for result := range table.StreamEventsBatch(context.Background(), ids, &BatchOptions{Events: &EventOptions{Limit: 1}}) {
+		if result.Err != nil || len(result.Events) != 1 {
+			t.Fatalf("Unexpected result for %s: %d events (%v)", result.ObjectId, len(result.Events), result.Err)
+		}
+		count++
+	}
+	if count != 20 {
+		t.Fatalf("Expected 20 results: %d", count)
+	}
+}
@@ -1,19 +1,33 @@
 package sky
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
-	DefaultPort = 8585
+	DefaultPort   = 8585
+	DefaultScheme = "http"
 )
 
 // A Client is what communicates with the server.
+//
+// Each operation has a Context variant which uses the context for
+// cancellation, deadlines and request-scoped values.
 type Client interface {
 	Host() string
 	SetHost(host string)
@@ -21,57 +35,205 @@ type Client interface {
 	Port() uint
 	SetPort(port uint)
 
+	// The URL scheme, either "http" or "https".
+	Scheme() string
+	SetScheme(scheme string)
+
+	// The TLS configuration used for HTTPS requests and streams.
+	TLSConfig() *tls.Config
+	SetTLSConfig(config *tls.Config)
+
+	// The path prefix added to every request and stream.
+	BasePath() string
+	SetBasePath(path string)
+
+	// The User-Agent header sent with requests and streams.
+	UserAgent() string
+
 	// Retrieves a single table from the server.
 	GetTable(name string) (Table, error)
+	GetTableContext(ctx context.Context, name string) (Table, error)
 
 	// Retrieves a list of all tables on the server.
 	GetTables() ([]Table, error)
+	GetTablesContext(ctx context.Context) ([]Table, error)
 
 	// Creates a table on the server.
 	CreateTable(table Table) error
+	CreateTableContext(ctx context.Context, table Table) error
 
 	// Deletes a table on the server.
 	DeleteTable(table Table) error
+	DeleteTableContext(ctx context.Context, table Table) error
 
 	// Opens a table agnostic event stream to the server.
 	Stream() (*EventStream, error)
+	StreamContext(ctx context.Context) (*EventStream, error)
 
 	// Checks if the server is currently running and available.
 	Ping() bool
+	PingContext(ctx context.Context) bool
 
-	// Sends and receives raw data sent to a URL path.
+	// Sends and receives raw data sent to a URL path. If ret is an
+	// *io.ReadCloser then it is set to the response body, which the caller
+	// must close, instead of being decoded into.
 	Send(method string, path string, data interface{}, ret interface{}) error
+	SendContext(ctx context.Context, method string, path string, data interface{}, ret interface{}) error
 
-	// Constructs a URL based on the client's host, port and a given path.
+	// Constructs a URL based on the client's host, port, base path and a
+	// given path.
 	URL(path string) string
 
+	// Opens a raw connection to the server, using TLS for HTTPS and
+	// tunneling through the client's proxy if there is one.
+	Dial() (net.Conn, error)
+	DialContext(ctx context.Context) (net.Conn, error)
+
 	// The HTTP client used by the client.
 	HTTPClient() *http.Client
 
+	// The authenticator applied to every request and stream.
+	Authenticator() Authenticator
+	SetAuthenticator(auth Authenticator)
+
+	// The policy for retrying failed requests. Nil disables retries.
+	RetryPolicy() *RetryPolicy
+	SetRetryPolicy(policy *RetryPolicy)
+
+	// The logger that requests and streams are logged to. Nil disables
+	// logging.
+	Logger() Logger
+
+	// The level that routine messages are logged at.
+	LogLevel() slog.Level
+
+	// The collector that request and stream metrics are recorded to. Nil
+	// disables metrics.
+	Metrics() Collector
+
+	// The tracer that creates spans for requests and streams. Nil disables
+	// tracing.
+	Tracer() Tracer
+
 	GetHost() string
 	GetPort() uint
 }
 
 type client struct {
-	host       string
-	port       uint
-	httpClient *http.Client
+	scheme       string
+	host         string
+	port         uint
+	basePath     string
+	userAgent    string
+	timeout      time.Duration
+	dialTimeout  time.Duration
+	proxy        func(*http.Request) (*url.URL, error)
+	tlsConfig    *tls.Config
+	auth         Authenticator
+	httpClient   *http.Client
+	ownTransport *http.Transport
+	err          error
+	retryPolicy  *RetryPolicy
+	logger       Logger
+	logLevel     slog.Level
+	logBodies    bool
+	metrics      Collector
+	tracer       Tracer
+
+	// Whether the server has each optional endpoint, once it is known.
+	endpoints sync.Map
 }
 
-func NewClient(host string) Client {
-	return &client{
-		host:       host,
-		port:       DefaultPort,
-		httpClient: &http.Client{},
+// responseBody is a response body handed to the caller, which releases the
+// request's timeout when it is closed.
+type responseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func NewClient(host string, options ...ClientOption) Client {
+	return newClient(DefaultScheme, host, DefaultPort, options)
+}
+
+func NewClientEx(host string, port uint, options ...ClientOption) Client {
+	return newClient(DefaultScheme, host, port, options)
+}
+
+// NewClientFromURL creates a client from a server URL such as
+// "https://sky.internal:8585/prefix". The port defaults to DefaultPort, the
+// path becomes the base path and any user info is sent with basic auth.
+// Options are applied after the settings from the URL. An error is returned
+// if the transport options can't be applied to a custom HTTP round tripper.
+func NewClientFromURL(rawurl string, options ...ClientOption) (Client, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("sky.Client: Invalid URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("sky.Client: Invalid URL scheme: %q", u.Scheme)
 	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("sky.Client: URL host required: %q", rawurl)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("sky.Client: URL cannot have a query or fragment: %q", rawurl)
+	}
+
+	port := uint64(DefaultPort)
+	if u.Port() != "" {
+		if port, err = strconv.ParseUint(u.Port(), 10, 16); err != nil || port == 0 {
+			return nil, fmt.Errorf("sky.Client: Invalid URL port: %q", u.Port())
+		}
+	}
+
+	var settings []ClientOption
+	if u.Path != "" {
+		settings = append(settings, WithBasePath(u.Path))
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		settings = append(settings, WithAuthenticator(BasicAuth(u.User.Username(), password)))
+	}
+	c := newClient(u.Scheme, u.Hostname(), uint(port), append(settings, options...))
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c, nil
 }
 
-func NewClientEx(host string, port uint) Client {
-	return &client{
+func newClient(scheme string, host string, port uint, options []ClientOption) *client {
+	c := &client{
+		scheme:     scheme,
 		host:       host,
 		port:       port,
+		userAgent:  "gosky/" + Version,
 		httpClient: &http.Client{},
+		logLevel:   slog.LevelDebug,
 	}
+	for _, option := range options {
+		option(c)
+	}
+
+	// Apply transport settings once the HTTP client is known. A custom
+	// round tripper can't be configured so the client is unusable instead
+	// of silently ignoring the settings.
+	if c.tlsConfig != nil || c.proxy != nil || c.dialTimeout > 0 {
+		t := c.transport()
+		if t == nil {
+			c.err = NewError(fmt.Sprintf("sky.Client: Transport options cannot be applied to %T", c.httpClient.Transport))
+			return c
+		}
+		if c.tlsConfig != nil {
+			t.TLSClientConfig = c.tlsConfig
+		}
+		if c.proxy != nil {
+			t.Proxy = c.proxy
+		}
+		if c.dialTimeout > 0 {
+			t.DialContext = (&net.Dialer{Timeout: c.dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+		}
+	}
+	return c
 }
 
 // Host retrieves the current host.
@@ -94,11 +256,111 @@ func (c *client) SetPort(port uint) {
 	c.port = port
 }
 
+// Scheme retrieves the URL scheme.
+func (c *client) Scheme() string {
+	return c.scheme
+}
+
+// SetScheme sets the URL scheme.
+func (c *client) SetScheme(scheme string) {
+	c.scheme = scheme
+}
+
+// TLSConfig retrieves the TLS configuration.
+func (c *client) TLSConfig() *tls.Config {
+	return c.tlsConfig
+}
+
+// SetTLSConfig sets the TLS configuration used by streams and by the HTTP
+// client's transport. A custom, non-*http.Transport round tripper on the HTTP
+// client must be configured separately.
+func (c *client) SetTLSConfig(config *tls.Config) {
+	c.tlsConfig = config
+	if t := c.transport(); t != nil {
+		t.TLSClientConfig = config
+	}
+}
+
+// BasePath retrieves the path prefix.
+func (c *client) BasePath() string {
+	return c.basePath
+}
+
+// SetBasePath sets the path prefix.
+func (c *client) SetBasePath(path string) {
+	c.basePath = cleanBasePath(path)
+}
+
+// UserAgent retrieves the User-Agent header.
+func (c *client) UserAgent() string {
+	return c.userAgent
+}
+
+// transport returns the HTTP client's transport so that it can be
+// configured. The default or caller's transport is replaced with a copy the
+// first time so that transports shared with other clients aren't modified.
+// Custom round trippers are not configurable and return nil.
+func (c *client) transport() *http.Transport {
+	if c.ownTransport != nil && c.httpClient.Transport == c.ownTransport {
+		return c.ownTransport
+	}
+	switch t := c.httpClient.Transport.(type) {
+	case nil:
+		c.ownTransport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		c.ownTransport = t.Clone()
+	default:
+		return nil
+	}
+	c.httpClient.Transport = c.ownTransport
+	return c.ownTransport
+}
+
 // The HTTP client.
 func (c *client) HTTPClient() *http.Client {
 	return c.httpClient
 }
 
+// The authenticator applied to every request and stream.
+func (c *client) Authenticator() Authenticator {
+	return c.auth
+}
+
+// Sets the authenticator applied to every request and stream.
+func (c *client) SetAuthenticator(auth Authenticator) {
+	c.auth = auth
+}
+
+// The policy for retrying failed requests.
+func (c *client) RetryPolicy() *RetryPolicy {
+	return c.retryPolicy
+}
+
+// Sets the policy for retrying failed requests.
+func (c *client) SetRetryPolicy(policy *RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// The logger that requests and streams are logged to.
+func (c *client) Logger() Logger {
+	return c.logger
+}
+
+// The level that routine messages are logged at.
+func (c *client) LogLevel() slog.Level {
+	return c.logLevel
+}
+
+// The collector that request and stream metrics are recorded to.
+func (c *client) Metrics() Collector {
+	return c.metrics
+}
+
+// The tracer that creates spans for requests and streams.
+func (c *client) Tracer() Tracer {
+	return c.tracer
+}
+
 func (c *client) GetHost() string {
 	return c.host
 }
@@ -107,14 +369,93 @@ func (c *client) GetPort() uint {
 	return c.port
 }
 
-// Constructs a URL based on the client's host, port and a given path.
+// Constructs a URL based on the client's host, port, base path and a given
+// path.
 func (c *client) URL(path string) string {
-	return fmt.Sprintf("http://%s:%d%s", c.Host(), c.Port(), path)
+	return fmt.Sprintf("%s://%s%s%s", c.Scheme(), net.JoinHostPort(c.Host(), strconv.Itoa(int(c.Port()))), c.basePath, path)
+}
+
+// Opens a raw connection to the server.
+func (c *client) Dial() (net.Conn, error) {
+	return c.DialContext(context.Background())
+}
+
+// Opens a raw connection to the server using the given context. HTTPS
+// connections use the client's TLS configuration, with the server name
+// defaulting to the host. If the client's proxy selects a proxy for the
+// server's URL then the connection is tunneled through it with CONNECT.
+func (c *client) DialContext(ctx context.Context) (net.Conn, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	address := net.JoinHostPort(c.host, strconv.Itoa(int(c.port)))
+	dialer := &net.Dialer{Timeout: c.dialTimeout}
+
+	proxyURL, err := c.proxyURL()
+	if err != nil {
+		return nil, err
+	}
+	var conn net.Conn
+	if proxyURL != nil {
+		conn, err = dialProxy(ctx, dialer, proxyURL, address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.scheme == "https" {
+		config := &tls.Config{}
+		if c.tlsConfig != nil {
+			config = c.tlsConfig.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName = c.host
+		}
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+	return conn, nil
+}
+
+// proxyURL returns the proxy for the server, if any. It uses the client's
+// proxy setting or else the proxy of the HTTP client's transport.
+func (c *client) proxyURL() (*url.URL, error) {
+	proxy := c.proxy
+	if proxy == nil {
+		switch t := c.httpClient.Transport.(type) {
+		case nil:
+			proxy = http.ProxyFromEnvironment
+		case *http.Transport:
+			proxy = t.Proxy
+		}
+	}
+	if proxy == nil {
+		return nil, nil
+	}
+	req, err := http.NewRequest("PATCH", c.URL("/events"), nil)
+	if err != nil {
+		return nil, err
+	}
+	return proxy(req)
 }
 
 // Sends low-level data to and from the server.
 func (c *client) Send(method string, path string, data interface{}, ret interface{}) error {
-	url := c.URL(path)
+	return c.SendContext(context.Background(), method, path, data, ret)
+}
+
+// Sends low-level data to and from the server using the given context.
+// Failed requests are retried according to the client's retry policy.
+func (c *client) SendContext(ctx context.Context, method string, path string, data interface{}, ret interface{}) error {
+	if c.err != nil {
+		return c.err
+	}
 
 	// Convert the data to JSON.
 	var err error
@@ -126,29 +467,82 @@ func (c *client) Send(method string, path string, data interface{}, ret interfac
 		}
 	}
 
-	// Create the request object.
-	req, err := http.NewRequest(method, url, strings.NewReader(string(body)))
-	if err != nil {
-		return err
+	ctx, end := startSpan(ctx, c, "sky "+method+" "+Endpoint(path), requestAttributes(method, path, body))
+	err = c.sendWithRetries(ctx, method, path, body, ret)
+	end(err)
+	return err
+}
+
+// sendWithRetries sends a request, refreshing credentials and retrying
+// failures according to the client's retry policy.
+func (c *client) sendWithRetries(ctx context.Context, method string, path string, body []byte, ret interface{}) error {
+	url := c.URL(path)
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := c.send(ctx, method, url, body, ret)
+		c.logRequest(ctx, method, path, body, start, attempt, err)
+		if c.metrics != nil {
+			c.metrics.ObserveRequest(method, Endpoint(path), time.Since(start), ErrorClass(err))
+		}
+
+		// Refresh rejected credentials and try again once.
+		if refresher, ok := c.auth.(Refresher); ok && !refreshed && IsUnauthorized(err) {
+			if err := refresher.Refresh(ctx); err != nil {
+				return err
+			}
+			refreshed = true
+			attempt--
+			continue
+		}
+
+		if err == nil || c.retryPolicy == nil || attempt >= c.retryPolicy.MaxAttempts || !c.retryPolicy.retryable(method, err) {
+			return err
+		}
+		if sleep(ctx, c.retryPolicy.backoff(attempt-1)) != nil {
+			return err
+		}
 	}
-	req.Header.Add("Content-Type", "application/json")
+}
 
-	// Send the request to the server.
-	resp, err := c.httpClient.Do(req)
+// logRequest logs the outcome of a request attempt. The body is redacted
+// unless the client was created with WithLogBodies.
+func (c *client) logRequest(ctx context.Context, method string, path string, body []byte, start time.Time, attempt int, err error) {
+	args := []any{"method", method, "path", path, "status", statusCode(err), "latency", time.Since(start), "attempt", attempt}
+	if len(body) > 0 {
+		if c.logBodies {
+			args = append(args, "body", string(body))
+		} else {
+			args = append(args, "body", redactedBody)
+		}
+	}
+	logMessage(ctx, c, err, "sky request", args...)
+}
+
+// send makes a single request to the server.
+func (c *client) send(ctx context.Context, method string, url string, body []byte, ret interface{}) error {
+	cancel := context.CancelFunc(func() {})
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+	resp, err := c.do(ctx, method, url, body)
 	if err != nil {
+		cancel()
 		return err
 	}
+
+	// Hand the body to the caller to decode incrementally. The timeout
+	// applies until the body is closed.
+	if rc, ok := ret.(*io.ReadCloser); ok && resp.StatusCode == http.StatusOK {
+		*rc = &responseBody{ReadCloser: resp.Body, cancel: cancel}
+		return nil
+	}
+	defer cancel()
 	defer resp.Body.Close()
 
-	// If we have a return object then deserialize to it.
+	// Convert error responses into an error with the server's message.
 	if resp.StatusCode != http.StatusOK {
-		h := make(map[string]interface{})
-		err := json.NewDecoder(resp.Body).Decode(h)
-		if message, ok := h["message"].(string); (err == nil || err == io.EOF) && ok {
-			return NewError(message)
-		} else {
-			return NewError(fmt.Sprintf("sky.Error: \"%s %s\" [%d]", method, url, resp.StatusCode))
-		}
+		return newResponseError(method, url, resp)
 	}
 
 	// Deserialize data into return object if we have one.
@@ -162,53 +556,188 @@ func (c *client) Send(method string, path string, data interface{}, ret interfac
 	return nil
 }
 
+// do creates a request and sends it to the server.
+func (c *client) do(ctx context.Context, method string, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	injectTrace(ctx, c, req.Header)
+	if c.auth != nil {
+		if err := c.auth.Authenticate(req); err != nil {
+			return nil, err
+		}
+	}
+
+	// Send the request to the server.
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, &Error{Method: method, URL: url, Err: err}
+	}
+	return resp, nil
+}
+
+// Closes the body and releases the request's timeout.
+func (b *responseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// dialProxy opens a connection to address through an HTTP proxy using
+// CONNECT.
+func dialProxy(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, address string) (net.Conn, error) {
+	proxyAddress := proxyURL.Host
+	if proxyURL.Port() == "" {
+		if proxyURL.Scheme == "https" {
+			proxyAddress = net.JoinHostPort(proxyURL.Hostname(), "443")
+		} else {
+			proxyAddress = net.JoinHostPort(proxyURL.Hostname(), "80")
+		}
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddress)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		req.SetBasicAuth(proxyURL.User.Username(), password)
+		req.Header.Set("Proxy-Authorization", req.Header.Get("Authorization"))
+		req.Header.Del("Authorization")
+	}
+	err = interruptible(ctx, conn, func() error {
+		if err := req.Write(conn); err != nil {
+			return err
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("sky.Client: Proxy CONNECT failed: %s", strings.TrimSpace(resp.Status))
+		}
+		return nil
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// statusCode returns the response status code for the result of a request.
+func statusCode(err error) int {
+	var e *Error
+	if err == nil {
+		return http.StatusOK
+	} else if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// newResponseError creates an error from a non-OK response, reading the
+// server's message from the body if there is one.
+func newResponseError(method string, url string, resp *http.Response) *Error {
+	e := &Error{StatusCode: resp.StatusCode, Method: method, URL: url}
+	h := make(map[string]interface{})
+	if err := json.NewDecoder(resp.Body).Decode(&h); err == nil || err == io.EOF {
+		e.Message, _ = h["message"].(string)
+	}
+	return e
+}
+
 func (c *client) GetTable(name string) (Table, error) {
+	return c.GetTableContext(context.Background(), name)
+}
+
+func (c *client) GetTableContext(ctx context.Context, name string) (Table, error) {
 	if name == "" {
 		return nil, errors.New("Table name required")
 	}
 	table := NewTable("", c)
-	if err := c.Send("GET", fmt.Sprintf("/tables/%s", name), nil, table); err != nil {
+	if err := c.SendContext(ctx, "GET", fmt.Sprintf("/tables/%s", name), nil, table); err != nil {
 		return nil, err
 	}
 	return table, nil
 }
 
 func (c *client) GetTables() ([]Table, error) {
+	return c.GetTablesContext(context.Background())
+}
+
+func (c *client) GetTablesContext(ctx context.Context) ([]Table, error) {
 	// Retrieve an array of table implementations.
 	tables := make([]*table, 0)
-	if err := c.Send("GET", "/tables", nil, &tables); err != nil {
+	if err := c.SendContext(ctx, "GET", "/tables", nil, &tables); err != nil {
 		return nil, err
 	}
 
 	// Convert to an array of table interfaces.
 	tmp := make([]Table, 0)
 	for _, t := range tables {
+		t.SetClient(c)
 		tmp = append(tmp, t)
 	}
 	return tmp, nil
 }
 
 func (c *client) CreateTable(table Table) error {
+	return c.CreateTableContext(context.Background(), table)
+}
+
+func (c *client) CreateTableContext(ctx context.Context, table Table) error {
 	if table == nil {
 		return errors.New("Table required")
 	}
 	table.SetClient(c)
-	return c.Send("POST", "/tables", table, table)
+	return c.SendContext(ctx, "POST", "/tables", table, table)
 }
 
 func (c *client) DeleteTable(table Table) error {
+	return c.DeleteTableContext(context.Background(), table)
+}
+
+func (c *client) DeleteTableContext(ctx context.Context, table Table) error {
 	if table == nil {
 		return errors.New("Table required")
 	}
 	table.SetClient(c)
-	return c.Send("DELETE", fmt.Sprintf("/tables/%s", table.Name()), nil, nil)
+	return c.SendContext(ctx, "DELETE", fmt.Sprintf("/tables/%s", table.Name()), nil, nil)
 }
 
 func (c *client) Ping() bool {
-	err := c.Send("GET", "/ping", nil, nil)
+	return c.PingContext(context.Background())
+}
+
+func (c *client) PingContext(ctx context.Context) bool {
+	err := c.SendContext(ctx, "GET", "/ping", nil, nil)
 	return err == nil
 }
 
 func (c *client) Stream() (*EventStream, error) {
-	return NewEventStream(c)
+	return c.StreamContext(context.Background())
+}
+
+func (c *client) StreamContext(ctx context.Context) (*EventStream, error) {
+	return NewEventStreamContext(ctx, c)
 }
@@ -1,6 +1,8 @@
 package sky
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -82,3 +84,44 @@ func TestTableEventStream(t *testing.T) {
 		}
 	})
 }
+
+// Ensure that a cancelled context interrupts requests and streams.
+func TestContextCancel(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := client.GetTablesContext(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected cancelled request: %v", err)
+		}
+		if _, err := table.StreamContext(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected cancelled stream: %v", err)
+		}
+	})
+}
+
+// Ensure that a stream can be flushed and closed with a deadline.
+func TestStreamContext(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		stream, err := table.StreamContext(ctx)
+		if err != nil {
+			t.Fatalf("Failed to create event stream: (%v)", err)
+		}
+		if err = stream.AddEventContext(ctx, "xyz", NewEvent(time.Now(), nil)); err != nil {
+			t.Fatalf("Failed to add event: (%v)", err)
+		}
+		if err = stream.FlushContext(ctx); err != nil {
+			t.Fatalf("Failed to flush stream: (%v)", err)
+		}
+		if err = stream.CloseContext(ctx); err != nil {
+			t.Fatalf("Closing stream failed: (%v)", err)
+		}
+		events, err := table.GetEventsContext(ctx, "xyz")
+		if err != nil || len(events) != 1 {
+			t.Fatalf("Failed to get 1 event back: %d events, (%v)", len(events), err)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/snormore/gosky"
+	"github.com/snormore/gosky/skyparquet"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// Output file formats.
+const (
+	NDJSONOutput  = "ndjson"
+	CSVOutput     = "csv"
+	ParquetOutput = "parquet"
+)
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// Exports object events to NDJSON, CSV or Parquet.
+func (a *app) exportEvents(ctx context.Context, args []string) error {
+	const syntax = "export [-output ndjson|csv|parquet] [-out file] [-ids file] <table> [id]..."
+
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	output := fs.String("output", "", "")
+	outPath := fs.String("out", "", "")
+	idsPath := fs.String("ids", "", "")
+	if err := fs.Parse(args); err != nil || fs.NArg() < 1 {
+		return usage(syntax)
+	}
+
+	// Determine the output format from the flag or the file extension.
+	if *output == "" {
+		*output = strings.TrimPrefix(strings.ToLower(filepath.Ext(*outPath)), ".")
+		if *output != CSVOutput && *output != ParquetOutput {
+			*output = NDJSONOutput
+		}
+	}
+	if *output != NDJSONOutput && *output != CSVOutput && *output != ParquetOutput {
+		return usage(syntax)
+	}
+
+	// Collect object ids from the arguments and the ids file. No ids
+	// exports the whole table, but an empty ids file exports nothing.
+	var objectIds []string
+	if fs.NArg() > 1 {
+		objectIds = fs.Args()[1:]
+	}
+	if *idsPath != "" {
+		ids, err := a.readIds(*idsPath)
+		if err != nil {
+			return err
+		}
+		objectIds = append(append([]string{}, objectIds...), ids...)
+	}
+
+	table, err := a.table(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	out := a.stdout
+	if *outPath != "" && *outPath != "-" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	var w sky.EventWriter
+	switch *output {
+	case CSVOutput:
+		w = sky.NewCSVWriter(out)
+	case ParquetOutput:
+		w = skyparquet.NewWriter(out)
+	default:
+		w = sky.NewNDJSONWriter(out)
+	}
+
+	count, err := sky.ExportContext(ctx, table, w, objectIds)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stderr, "sky export: %d events exported\n", count)
+	return nil
+}
+
+// readIds reads object ids from a file or stdin, one per line.
+func (a *app) readIds(path string) ([]string, error) {
+	r := a.stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	ids := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, scanner.Err()
+}
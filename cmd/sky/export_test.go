@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Ensure that events are exported for given objects or a whole table.
+func TestExport(t *testing.T) {
+	server, _ := newImportServer(t)
+	defer server.Close()
+
+	input := `{"id": "u1", "timestamp": "2024-01-01T00:00:00Z", "name": "bob", "age": 41}
+{"id": "u2", "timestamp": "2024-01-01T00:00:00Z", "score": 1.5}
+`
+	if code, _, stderr := executeInput(server, input, "import", "users"); code != 0 {
+		t.Fatalf("Unable to import: %d %s", code, stderr)
+	}
+
+	code, stdout, stderr := execute(server, "export", "users", "u2")
+	if code != 0 || stdout != `{"id":"u2","score":1.5,"timestamp":"2024-01-01T00:00:00Z"}`+"\n" || !strings.Contains(stderr, "1 events exported") {
+		t.Fatalf("Unexpected NDJSON export: %d %q %s", code, stdout, stderr)
+	}
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "users.csv")
+	if code, _, stderr := executeInput(server, "u1\nu2\n", "export", "-ids", "-", "-out", out, "users"); code != 0 {
+		t.Fatalf("Unable to export CSV: %d %s", code, stderr)
+	}
+	if b, _ := os.ReadFile(out); string(b) != "id,timestamp,active,age,name,score\nu1,2024-01-01T00:00:00Z,,41,bob,\nu2,2024-01-01T00:00:00Z,,,,1.5\n" {
+		t.Fatalf("Unexpected CSV export: %q", b)
+	}
+
+	// An empty ids file exports nothing rather than the whole table.
+	if code, stdout, stderr := executeInput(server, "", "export", "-ids", "-", "users"); code != 0 || stdout != "" || !strings.Contains(stderr, "0 events exported") {
+		t.Fatalf("Unexpected empty export: %d %q %s", code, stdout, stderr)
+	}
+
+	out = filepath.Join(dir, "users.parquet")
+	if code, _, stderr := execute(server, "export", "-out", out, "users"); code != 0 || !strings.Contains(stderr, "2 events exported") {
+		t.Fatalf("Unable to export Parquet: %d %s", code, stderr)
+	}
+	if b, _ := os.ReadFile(out); !strings.HasPrefix(string(b), "PAR1") {
+		t.Fatalf("Expected Parquet file")
+	}
+}
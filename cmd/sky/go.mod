@@ -0,0 +1,27 @@
+module github.com/snormore/gosky/cmd/sky
+
+go 1.26.0
+
+require (
+	github.com/snormore/gosky v0.0.0-00010101000000-000000000000
+	github.com/snormore/gosky/skyparquet v0.0.0-00010101000000-000000000000
+	golang.org/x/term v0.46.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/klauspost/compress v1.19.1 // indirect
+	github.com/parquet-go/bitpack v1.0.0 // indirect
+	github.com/parquet-go/jsonlite v1.0.0 // indirect
+	github.com/parquet-go/parquet-go v0.32.0 // indirect
+	github.com/pierrec/lz4/v4 v4.1.21 // indirect
+	github.com/twpayne/go-geom v1.6.1 // indirect
+	golang.org/x/sys v0.48.0 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/snormore/gosky => ../../
+
+replace github.com/snormore/gosky/skyparquet => ../../skyparquet
@@ -0,0 +1,514 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snormore/gosky"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// Input formats.
+const (
+	NDJSONInput = "ndjson"
+	CSVInput    = "csv"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// importer converts input records into events for a table.
+type importer struct {
+	properties      map[string]*sky.Property
+	idColumn        string
+	timestampColumn string
+	timestampFormat string
+	mapping         map[string]string
+	ignoreUnknown   bool
+}
+
+// A recordReader reads records from an input format. Each record is returned
+// with its raw form so that it can be written to the rejects file.
+type recordReader interface {
+	Read() (fields map[string]interface{}, raw interface{}, err error)
+}
+
+// A rejectWriter writes rejected records in their input format.
+type rejectWriter interface {
+	Write(raw interface{}) error
+	Flush() error
+}
+
+type ndjsonReader struct {
+	r *bufio.Reader
+}
+
+// A csvReader keeps the input that the CSV reader has read ahead so that
+// malformed rows can be rejected with their raw text.
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+	input  bytes.Buffer
+	offset int64
+}
+
+type ndjsonRejects struct {
+	w *bufio.Writer
+}
+
+type csvRejects struct {
+	w      *csv.Writer
+	out    io.Writer
+	header []string
+}
+
+// importResult is the summary of an import.
+type importResult struct {
+	Imported int `json:"imported"`
+	Rejected int `json:"rejected"`
+	Offset   int `json:"offset"`
+}
+
+// mappingFlag parses repeated column=property flags.
+type mappingFlag map[string]string
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+//--------------------------------------
+// Command
+//--------------------------------------
+
+// Imports NDJSON or CSV events into a table through an event stream.
+func (a *app) importEvents(ctx context.Context, args []string) error {
+	const syntax = "import [-input ndjson|csv] [-id column] [-timestamp column] [-timestamp-format format] [-map column=property]... [-ignore-unknown] [-rejects file] [-offset n] [-commit-every n] [-progress interval] <table> [file]"
+
+	im := &importer{mapping: make(mappingFlag)}
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	input := fs.String("input", "", "")
+	fs.StringVar(&im.idColumn, "id", "id", "")
+	fs.StringVar(&im.timestampColumn, "timestamp", "timestamp", "")
+	fs.StringVar(&im.timestampFormat, "timestamp-format", "", "")
+	fs.Var(mappingFlag(im.mapping), "map", "")
+	fs.BoolVar(&im.ignoreUnknown, "ignore-unknown", false, "")
+	rejectsPath := fs.String("rejects", "", "")
+	offset := fs.Int("offset", 0, "")
+	commitEvery := fs.Int("commit-every", 10000, "")
+	progress := fs.Duration("progress", 5*time.Second, "")
+	if err := fs.Parse(args); err != nil || fs.NArg() < 1 || fs.NArg() > 2 || *offset < 0 || *commitEvery < 1 {
+		return usage(syntax)
+	}
+
+	// Determine the input format from the flag or the file extension.
+	path := fs.Arg(1)
+	if *input == "" {
+		*input = NDJSONInput
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			*input = CSVInput
+		}
+	}
+	if *input != NDJSONInput && *input != CSVInput {
+		return usage(syntax)
+	}
+
+	// Open the input and the rejects file.
+	var r io.Reader = a.stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var reader recordReader
+	if *input == CSVInput {
+		cr, err := newCSVReader(r)
+		if err != nil {
+			return err
+		}
+		reader = cr
+	} else {
+		reader = &ndjsonReader{r: bufio.NewReader(r)}
+	}
+
+	var rejects rejectWriter
+	if *rejectsPath != "" {
+		f, err := os.Create(*rejectsPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if cr, ok := reader.(*csvReader); ok {
+			rejects = &csvRejects{w: csv.NewWriter(f), out: f, header: cr.header}
+		} else {
+			rejects = &ndjsonRejects{w: bufio.NewWriter(f)}
+		}
+		defer rejects.Flush()
+	}
+
+	// Retrieve the table's properties for type coercion.
+	table, err := a.table(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	properties, err := table.GetPropertiesContext(ctx)
+	if err != nil {
+		return err
+	}
+	im.properties = make(map[string]*sky.Property)
+	for _, p := range properties {
+		im.properties[p.Name] = p
+	}
+
+	stream, err := table.StreamContext(ctx)
+	if err != nil {
+		return err
+	}
+	stream.SetReconnectPolicy(&sky.ReconnectPolicy{MaxAttempts: 5, Backoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second})
+
+	result := &importResult{Offset: *offset}
+	committed := *offset
+	lastProgress := time.Now()
+	fail := func(err error) error {
+		stream.CloseContext(ctx)
+		return fmt.Errorf("import stopped after row %d: %v (resume with -offset %d)", result.Offset, err, committed)
+	}
+
+	for row := 1; ; row++ {
+		fields, raw, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil && raw == nil {
+			return fail(err)
+		}
+		if row <= *offset {
+			continue
+		}
+
+		// Convert the record or reject it.
+		var objectId string
+		var event *sky.Event
+		if err == nil {
+			objectId, event, err = im.event(fields)
+		}
+		if err != nil {
+			result.Rejected++
+			fmt.Fprintf(a.stderr, "sky import: row %d rejected: %v\n", row, err)
+			if rejects != nil {
+				if err := rejects.Write(raw); err != nil {
+					return fail(err)
+				}
+			}
+		} else if err := stream.AddEventContext(ctx, objectId, event); err != nil {
+			return fail(err)
+		} else {
+			result.Imported++
+		}
+		result.Offset = row
+
+		// Commit periodically so that the import can be resumed.
+		if (result.Offset-*offset)%*commitEvery == 0 {
+			if err := stream.CommitContext(ctx); err != nil {
+				return fail(err)
+			}
+			committed = result.Offset
+		}
+		if *progress > 0 && time.Since(lastProgress) >= *progress {
+			fmt.Fprintf(a.stderr, "sky import: %d rows imported, %d rejected, %d committed\n", result.Imported, result.Rejected, committed)
+			lastProgress = time.Now()
+		}
+	}
+
+	if err := stream.CloseContext(ctx); err != nil {
+		return fmt.Errorf("import failed to commit: %v (resume with -offset %d)", err, committed)
+	}
+	return a.print(result, []string{"IMPORTED", "REJECTED", "OFFSET"},
+		[][]string{{strconv.Itoa(result.Imported), strconv.Itoa(result.Rejected), strconv.Itoa(result.Offset)}})
+}
+
+//--------------------------------------
+// Importer
+//--------------------------------------
+
+// event converts a record into an object identifier and event.
+func (im *importer) event(fields map[string]interface{}) (string, *sky.Event, error) {
+	objectId, err := im.objectId(fields[im.idColumn])
+	if err != nil {
+		return "", nil, err
+	}
+	timestamp, err := im.timestamp(fields[im.timestampColumn])
+	if err != nil {
+		return "", nil, err
+	}
+
+	data := make(map[string]interface{})
+	for column, value := range fields {
+		if column == im.idColumn || column == im.timestampColumn || value == nil || value == "" {
+			continue
+		}
+		name := column
+		if mapped, ok := im.mapping[column]; ok {
+			if mapped == "" {
+				continue
+			}
+			name = mapped
+		}
+		p := im.properties[name]
+		if p == nil {
+			if im.ignoreUnknown {
+				continue
+			}
+			return "", nil, fmt.Errorf("unknown property: %s", name)
+		}
+		if data[name], err = coerce(value, p.DataType); err != nil {
+			return "", nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return objectId, sky.NewEvent(timestamp, data), nil
+}
+
+// objectId converts an object identifier column.
+func (im *importer) objectId(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			return v, nil
+		}
+	case json.Number:
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("missing object id column: %s", im.idColumn)
+}
+
+// timestamp converts a timestamp column using the timestamp format, which
+// is RFC 3339 by default, "unix" or "unixms" for epoch times or a Go time
+// layout.
+func (im *importer) timestamp(value interface{}) (time.Time, error) {
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = v
+	case json.Number:
+		str = v.String()
+	}
+	if str == "" {
+		return time.Time{}, fmt.Errorf("missing timestamp column: %s", im.timestampColumn)
+	}
+
+	switch im.timestampFormat {
+	case "":
+		return sky.ParseTimestamp(str)
+	case "unix", "unixms":
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp: %q", str)
+		}
+		if im.timestampFormat == "unixms" {
+			f /= 1000
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+	}
+	return time.Parse(im.timestampFormat, str)
+}
+
+//--------------------------------------
+// Readers
+//--------------------------------------
+
+// Reads the next JSON object line, skipping blank lines.
+func (r *ndjsonReader) Read() (map[string]interface{}, interface{}, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		raw := bytes.TrimRight(line, "\r\n")
+
+		fields := make(map[string]interface{})
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&fields); err != nil {
+			return nil, raw, fmt.Errorf("invalid JSON: %v", err)
+		}
+
+		// Properties may also be nested under "data".
+		if data, ok := fields["data"].(map[string]interface{}); ok {
+			delete(fields, "data")
+			for k, v := range data {
+				fields[k] = v
+			}
+		}
+		return fields, raw, nil
+	}
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := &csvReader{}
+	cr.r = csv.NewReader(io.TeeReader(r, &cr.input))
+	header, err := cr.r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CSV header: %v", err)
+	}
+	cr.header = header
+	cr.consume()
+	return cr, nil
+}
+
+// Reads the next CSV record keyed by the header columns. A malformed row is
+// returned with its raw text so that it can be rejected.
+func (r *csvReader) Read() (map[string]interface{}, interface{}, error) {
+	record, err := r.r.Read()
+	line := r.consume()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, bytes.TrimRight(line, "\r\n"), err
+		}
+		return nil, nil, err
+	}
+	fields := make(map[string]interface{}, len(record))
+	for i, value := range record {
+		fields[r.header[i]] = value
+	}
+	return fields, record, nil
+}
+
+// consume returns the input that the CSV reader has parsed since the last
+// call and discards it.
+func (r *csvReader) consume() []byte {
+	end := r.r.InputOffset()
+	line := append([]byte(nil), r.input.Next(int(end-r.offset))...)
+	r.offset = end
+	return line
+}
+
+//--------------------------------------
+// Rejects
+//--------------------------------------
+
+func (w *ndjsonRejects) Write(raw interface{}) error {
+	w.w.Write(raw.([]byte))
+	return w.w.WriteByte('\n')
+}
+
+func (w *ndjsonRejects) Flush() error {
+	return w.w.Flush()
+}
+
+// Writes the header before the first rejected record. Rows that couldn't be
+// parsed are written as they were read.
+func (w *csvRejects) Write(raw interface{}) error {
+	if w.header != nil {
+		if err := w.w.Write(w.header); err != nil {
+			return err
+		}
+		w.header = nil
+	}
+	if line, ok := raw.([]byte); ok {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w.out, "%s\n", line)
+		return err
+	}
+	return w.w.Write(raw.([]string))
+}
+
+func (w *csvRejects) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+//--------------------------------------
+// Mapping Flag
+//--------------------------------------
+
+func (m mappingFlag) String() string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Adds a column=property pair. A blank property skips the column.
+func (m mappingFlag) Set(value string) error {
+	column, property, ok := strings.Cut(value, "=")
+	if !ok || column == "" {
+		return fmt.Errorf("invalid mapping: %q", value)
+	}
+	m[column] = property
+	return nil
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// coerce converts a JSON or CSV value to a property's data type.
+func coerce(value interface{}, dataType string) (interface{}, error) {
+	var str string
+	switch v := value.(type) {
+	case string:
+		str = v
+	case json.Number:
+		str = v.String()
+	case bool:
+		str = strconv.FormatBool(v)
+	default:
+		return nil, fmt.Errorf("unsupported value: %v", value)
+	}
+
+	switch dataType {
+	case sky.String, sky.Factor:
+		return str, nil
+	case sky.Integer:
+		if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(str, 64); err == nil && f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+			return int64(f), nil
+		}
+	case sky.Float:
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return f, nil
+		}
+	case sky.Boolean:
+		if b, err := strconv.ParseBool(str); err == nil {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s: %q", dataType, str)
+}
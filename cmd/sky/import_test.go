@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky"
+	"github.com/snormore/gosky/skytest"
+)
+
+// newImportServer starts a server with a table for importing into.
+func newImportServer(t *testing.T) (*skytest.Server, sky.Table) {
+	server := skytest.NewServer()
+	client := sky.NewClientEx(server.Host(), server.Port())
+	table := sky.NewTable("users", nil)
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to create table: %v", err)
+	}
+	table.CreateProperty(sky.NewProperty("name", false, sky.String))
+	table.CreateProperty(sky.NewProperty("age", false, sky.Integer))
+	table.CreateProperty(sky.NewProperty("score", false, sky.Float))
+	table.CreateProperty(sky.NewProperty("active", true, sky.Boolean))
+	return server, table
+}
+
+// Ensure that NDJSON events are imported and coerced to the property types.
+func TestImportNDJSON(t *testing.T) {
+	server, table := newImportServer(t)
+	defer server.Close()
+
+	input := `{"id": "u1", "timestamp": "2024-01-01T00:00:00Z", "name": "bob", "age": "41", "score": 2}
+{"id": "u1", "timestamp": "2024-01-02T00:00:00Z", "data": {"active": "true", "age": 42.0}}
+
+{"id": "u2", "timestamp": "2024-01-01T00:00:00Z", "age": "old"}
+{"id": "u2", "timestamp": "2024-01-01T00:00:00Z", "color": "red"}
+not json
+`
+	rejects := filepath.Join(t.TempDir(), "rejects.ndjson")
+	code, stdout, stderr := executeInput(server, input, "import", "-rejects", rejects, "users")
+	if code != 0 || stdout != "IMPORTED  REJECTED  OFFSET\n2         3         5\n" {
+		t.Fatalf("Unexpected import: %d %q %s", code, stdout, stderr)
+	}
+	for _, msg := range []string{"row 3 rejected: age: invalid integer", "row 4 rejected: unknown property: color", "row 5 rejected: invalid JSON"} {
+		if !strings.Contains(stderr, msg) {
+			t.Fatalf("Expected %q: %s", msg, stderr)
+		}
+	}
+	if b, _ := os.ReadFile(rejects); strings.Count(string(b), "\n") != 3 || !strings.HasSuffix(string(b), "not json\n") {
+		t.Fatalf("Unexpected rejects file: %q", b)
+	}
+
+	events, err := table.GetEvents("u1")
+	if err != nil || len(events) != 2 {
+		t.Fatalf("Expected 2 events: %d (%v)", len(events), err)
+	}
+	if d := events[0].Data; d["name"] != "bob" || d["age"] != float64(41) || d["score"] != float64(2) {
+		t.Fatalf("Unexpected first event: %v", d)
+	}
+	if d := events[1].Data; d["active"] != true || d["age"] != float64(42) {
+		t.Fatalf("Unexpected second event: %v", d)
+	}
+}
+
+// Ensure that CSV events are imported with a column mapping, a timestamp
+// format and a resume offset.
+func TestImportCSV(t *testing.T) {
+	server, table := newImportServer(t)
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.csv")
+	os.WriteFile(path, []byte("user,time,full_name,age,notes\nu1,1704067200,alice,30,x\nu1,1704153600,alice,31,y\nu1,1704240000,alice,thirty,z\nu1,1704326400,\"al\"ice,32,w\nu1,1704412800,a\"l,33,v\nu1,1704499200,al,34\n"), 0600)
+	rejects := filepath.Join(dir, "rejects.csv")
+
+	code, stdout, stderr := execute(server, "-format", "json", "import", "-id", "user", "-timestamp", "time", "-timestamp-format", "unix",
+		"-map", "full_name=name", "-map", "notes=", "-offset", "1", "-rejects", rejects, "users", path)
+	if code != 0 || stdout != "{\n  \"imported\": 1,\n  \"rejected\": 4,\n  \"offset\": 6\n}\n" {
+		t.Fatalf("Unexpected import: %d %q %s", code, stdout, stderr)
+	}
+	if b, _ := os.ReadFile(rejects); string(b) != "user,time,full_name,age,notes\nu1,1704240000,alice,thirty,z\nu1,1704326400,\"al\"ice,32,w\nu1,1704412800,a\"l,33,v\nu1,1704499200,al,34\n" {
+		t.Fatalf("Unexpected rejects file: %q", b)
+	}
+
+	events, err := table.GetEvents("u1")
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Expected 1 event: %d (%v)", len(events), err)
+	}
+	if !events[0].Timestamp.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) || events[0].Data["name"] != "alice" || events[0].Data["age"] != float64(31) {
+		t.Fatalf("Unexpected event: %v %v", events[0].Timestamp, events[0].Data)
+	}
+}
+
+// Ensure that an import reports where to resume after a failure.
+func TestImportResume(t *testing.T) {
+	server := skytest.NewUnstartedServer()
+	handler := server.Config.Handler
+	var streams int32
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Break the second stream by deleting a property it uses.
+		if r.Method == "PATCH" && r.URL.Path == "/tables/users/events" && atomic.AddInt32(&streams, 1) == 2 {
+			table := sky.NewTable("users", sky.NewClientEx(server.Host(), server.Port()))
+			table.DeleteProperty(sky.NewProperty("age", false, ""))
+		}
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+
+	client := sky.NewClientEx(server.Host(), server.Port())
+	table := sky.NewTable("users", nil)
+	client.CreateTable(table)
+	table.CreateProperty(sky.NewProperty("age", false, sky.Integer))
+
+	var input strings.Builder
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&input, `{"id": "u1", "timestamp": "2024-01-01T%02d:00:00Z", "age": %d}`+"\n", i, i)
+	}
+	code, _, stderr := executeInput(server, input.String(), "import", "-commit-every", "4", "users")
+	if code != 1 || !strings.Contains(stderr, "import stopped after row 8") || !strings.Contains(stderr, "resume with -offset 4") {
+		t.Fatalf("Expected import failure: %d %s", code, stderr)
+	}
+
+	table.CreateProperty(sky.NewProperty("age", false, sky.Integer))
+	if code, _, stderr := executeInput(server, input.String(), "import", "-offset", "4", "users"); code != 0 {
+		t.Fatalf("Unable to resume import: %d %s", code, stderr)
+	}
+	if events, err := table.GetEvents("u1"); err != nil || len(events) != 10 {
+		t.Fatalf("Expected 10 events: %d (%v)", len(events), err)
+	}
+}
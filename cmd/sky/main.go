@@ -0,0 +1,247 @@
+// Command sky administers tables and properties on a Sky server.
+//
+// Usage:
+//
+//	sky [flags] <command> [arguments]
+//
+// The commands are:
+//
+//	ping                                     Check that the server is available.
+//	tables list                              List tables.
+//	tables create <table>                    Create a table.
+//	tables delete <table>                    Delete a table.
+//	properties list <table>                  List the properties of a table.
+//	properties create [-transient] <table> <name> <type>
+//	                                         Create a property.
+//	properties update <table> <name> <new name>
+//	                                         Rename a property.
+//	properties delete <table> <name>         Delete a property.
+//	stats <table>                            Show table statistics.
+//	import [flags] <table> [file]            Import NDJSON or CSV events.
+//	export [flags] <table> [id]...           Export events to NDJSON, CSV or Parquet.
+//	query [-f file] <table> [query]          Run a raw JSON query or start a query session.
+//
+// The import command reads events from a file or stdin and streams them to
+// the table. Each record has an object id column, a timestamp column and
+// property columns whose values are converted to the property data types.
+// Records that can't be converted are reported and written to the -rejects
+// file in their original format. Progress is committed every -commit-every
+// records and an interrupted import can be resumed by passing the last
+// committed record count as -offset. Replaying records is safe since events
+// with the same object and timestamp are merged.
+//
+// The export command writes the events of the given objects, or of every
+// object if the server supports listing them, with an id column, a timestamp
+// column and a typed column for each property.
+//
+// The query command runs a raw JSON query given as an argument or a file and
+// prints each selection as a pivot table, or the raw results with -format
+// json. Without a query it starts an interactive session which reads
+// queries over one or more lines, with history saved to ~/.sky_history and
+// tab completion of table names, properties and query keywords. Type \help
+// in the session for its commands.
+//
+// Connection settings are read from the flags, then the SKY_URL, SKY_TIMEOUT,
+// SKY_TOKEN and SKY_CA_FILE environment variables and then the profile
+// selected from the SKY_CONFIG file. See the skyconfig package for details.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/snormore/gosky"
+	"github.com/snormore/gosky/skyconfig"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// Output formats.
+const (
+	TableFormat = "table"
+	JSONFormat  = "json"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// app holds the global settings and streams of a single invocation.
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	loader skyconfig.Loader
+	format string
+	client sky.Client
+}
+
+// A command runs a subcommand with its arguments.
+type command func(ctx context.Context, args []string) error
+
+// usageError is returned for invalid arguments.
+type usageError struct {
+	message string
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	a := &app{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("sky", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&a.loader.Override.URL, "url", "", "server URL (default $SKY_URL)")
+	fs.DurationVar(&a.loader.Override.Timeout, "timeout", 0, "request timeout (default $SKY_TIMEOUT)")
+	fs.StringVar(&a.loader.Override.Token, "token", "", "bearer token (default $SKY_TOKEN)")
+	fs.StringVar(&a.loader.Override.CAFile, "ca-file", "", "PEM file of trusted certificate authorities (default $SKY_CA_FILE)")
+	fs.StringVar(&a.loader.Path, "config", "", "config file (default $SKY_CONFIG)")
+	fs.StringVar(&a.loader.Profile, "profile", "", "config file profile (default $SKY_PROFILE)")
+	fs.StringVar(&a.format, "format", TableFormat, "output format: table or json")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: sky [flags] <command> [arguments]")
+		fmt.Fprintln(stderr, "\ncommands: ping, tables, properties, stats, import, export, query\n\nflags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if a.format != TableFormat && a.format != JSONFormat {
+		fmt.Fprintf(stderr, "sky: invalid format: %q\n", a.format)
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	commands := map[string]command{
+		"ping":       a.ping,
+		"tables":     a.tables,
+		"properties": a.properties,
+		"stats":      a.stats,
+		"import":     a.importEvents,
+		"export":     a.exportEvents,
+		"query":      a.query,
+	}
+	cmd := commands[fs.Arg(0)]
+	if cmd == nil {
+		fmt.Fprintf(stderr, "sky: unknown command: %q\n", fs.Arg(0))
+		return 2
+	}
+
+	err := cmd(ctx, fs.Args()[1:])
+	var usage *usageError
+	switch {
+	case errors.As(err, &usage):
+		fmt.Fprintln(stderr, usage)
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "sky: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// usage returns a usage error for a command.
+func usage(format string, v ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, v...)}
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// The error message.
+func (e *usageError) Error() string {
+	return "usage: sky " + e.message
+}
+
+// connect creates the client from the connection settings.
+func (a *app) connect() (sky.Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+	config, err := a.loader.Load()
+	if err != nil {
+		return nil, err
+	}
+	if a.client, err = config.NewClient(sky.WithUserAgent("sky-cli/" + sky.Version)); err != nil {
+		return nil, err
+	}
+	return a.client, nil
+}
+
+// table connects and returns a table by name without retrieving it.
+func (a *app) table(name string) (sky.Table, error) {
+	client, err := a.connect()
+	if err != nil {
+		return nil, err
+	}
+	return sky.NewTable(name, client), nil
+}
+
+// print writes a value as indented JSON or as a table of rows.
+func (a *app) print(v interface{}, header []string, rows [][]string) error {
+	if a.format == JSONFormat {
+		encoder := json.NewEncoder(a.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+	w := tabwriter.NewWriter(a.stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// Checks that the server is available.
+func (a *app) ping(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return usage("ping")
+	}
+	client, err := a.connect()
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	if err := client.SendContext(ctx, "GET", "/ping", nil, nil); err != nil {
+		return err
+	}
+	latency := time.Since(start)
+	return a.print(map[string]interface{}{"url": client.URL(""), "latency": latency.Seconds()},
+		[]string{"URL", "LATENCY"}, [][]string{{client.URL(""), latency.Round(time.Microsecond).String()}})
+}
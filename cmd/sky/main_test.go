@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+// execute runs the command line against a server and returns the exit code
+// and output.
+func execute(server *skytest.Server, args ...string) (int, string, string) {
+	return executeInput(server, "", args...)
+}
+
+// executeInput runs the command line with the given input.
+func executeInput(server *skytest.Server, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	args = append([]string{"-url", server.URL}, args...)
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// Ensure that tables can be created, listed and deleted.
+func TestTables(t *testing.T) {
+	server := skytest.NewServer()
+	defer server.Close()
+
+	if code, _, stderr := execute(server, "tables", "create", "users"); code != 0 {
+		t.Fatalf("Unable to create table: %d %s", code, stderr)
+	}
+	if code, _, stderr := execute(server, "tables", "create", "users"); code != 1 || !strings.Contains(stderr, "409") {
+		t.Fatalf("Expected conflict: %d %s", code, stderr)
+	}
+	if code, stdout, _ := execute(server, "tables", "list"); code != 0 || stdout != "NAME\nusers\n" {
+		t.Fatalf("Unexpected table list: %d %q", code, stdout)
+	}
+	if code, stdout, _ := execute(server, "-format", "json", "tables", "list"); code != 0 || stdout != "[\n  {\n    \"name\": \"users\"\n  }\n]\n" {
+		t.Fatalf("Unexpected JSON table list: %d %q", code, stdout)
+	}
+	if code, _, stderr := execute(server, "tables", "delete", "users"); code != 0 {
+		t.Fatalf("Unable to delete table: %d %s", code, stderr)
+	}
+}
+
+// Ensure that properties can be created, listed, renamed and deleted.
+func TestProperties(t *testing.T) {
+	server := skytest.NewServer()
+	defer server.Close()
+
+	execute(server, "tables", "create", "users")
+	if code, _, stderr := execute(server, "properties", "create", "users", "name", "string"); code != 0 {
+		t.Fatalf("Unable to create property: %d %s", code, stderr)
+	}
+	if code, _, stderr := execute(server, "properties", "create", "-transient", "users", "action", "factor"); code != 0 {
+		t.Fatalf("Unable to create transient property: %d %s", code, stderr)
+	}
+	if code, _, stderr := execute(server, "properties", "create", "users", "age", "number"); code != 2 || !strings.Contains(stderr, "usage:") {
+		t.Fatalf("Expected usage error: %d %s", code, stderr)
+	}
+	if code, _, stderr := execute(server, "properties", "update", "users", "name", "fullName"); code != 0 {
+		t.Fatalf("Unable to rename property: %d %s", code, stderr)
+	}
+
+	code, stdout, _ := execute(server, "properties", "list", "users")
+	if code != 0 || stdout != "ID  NAME      TYPE    TRANSIENT\n-1  action    factor  true\n1   fullName  string  false\n" {
+		t.Fatalf("Unexpected property list: %d %q", code, stdout)
+	}
+
+	if code, _, stderr := execute(server, "properties", "delete", "users", "fullName"); code != 0 {
+		t.Fatalf("Unable to delete property: %d %s", code, stderr)
+	}
+	var properties []map[string]interface{}
+	_, stdout, _ = execute(server, "-format", "json", "properties", "list", "users")
+	if err := json.Unmarshal([]byte(stdout), &properties); err != nil || len(properties) != 1 || properties[0]["name"] != "action" {
+		t.Fatalf("Unexpected JSON property list: %v (%v)", properties, err)
+	}
+}
+
+// Ensure that stats and ping are reported.
+func TestStatsPing(t *testing.T) {
+	server := skytest.NewServer()
+	defer server.Close()
+
+	execute(server, "tables", "create", "users")
+	if code, stdout, _ := execute(server, "stats", "users"); code != 0 || stdout != "COUNT\n0\n" {
+		t.Fatalf("Unexpected stats: %d %q", code, stdout)
+	}
+	if code, _, stderr := execute(server, "stats", "missing"); code != 1 || !strings.Contains(stderr, "404") {
+		t.Fatalf("Expected not found: %d %s", code, stderr)
+	}
+	if code, stdout, _ := execute(server, "ping"); code != 0 || !strings.HasPrefix(stdout, "URL") {
+		t.Fatalf("Unexpected ping: %d %q", code, stdout)
+	}
+}
+
+// Ensure that invalid command lines are rejected with usage.
+func TestUsage(t *testing.T) {
+	server := skytest.NewServer()
+	defer server.Close()
+
+	for _, args := range [][]string{{}, {"unknown"}, {"tables"}, {"tables", "create"}, {"-format", "xml", "ping"}} {
+		if code, _, _ := execute(server, args...); code != 2 {
+			t.Fatalf("Expected usage error for %v: %d", args, code)
+		}
+	}
+
+	var stderr bytes.Buffer
+	if code := run(context.Background(), []string{"-url", "ftp://sky", "ping"}, nil, &stderr, &stderr); code != 1 || !strings.Contains(stderr.String(), "override: url:") {
+		t.Fatalf("Expected invalid url error: %d %s", code, stderr.String())
+	}
+}
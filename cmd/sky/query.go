@@ -0,0 +1,510 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/snormore/gosky"
+	"golang.org/x/term"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// The maximum number of entries kept in the REPL history file.
+const maxHistory = 500
+
+// Words completed in queries in addition to the table's properties.
+var queryKeywords = []string{
+	"steps", "type", "selection", "condition", "name", "dimensions", "fields",
+	"expression", "within", "withinUnits", "sessionIdleTime",
+	"count()", "sum(", "min(", "max(",
+}
+
+// REPL commands.
+var replCommands = []string{`\table`, `\properties`, `\format`, `\help`, `\quit`}
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// repl is an interactive query session.
+type repl struct {
+	app        *app
+	ctx        context.Context
+	out        io.Writer
+	table      sky.Table
+	tables     []string
+	properties []*sky.Property
+}
+
+// pivotSelection describes the columns of a selection's results.
+type pivotSelection struct {
+	name       string
+	dimensions []string
+	fields     []string
+}
+
+// fileHistory is a term.History that is persisted to a file.
+type fileHistory struct {
+	path    string
+	entries []string
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+//--------------------------------------
+// Command
+//--------------------------------------
+
+// Runs a raw query once or starts an interactive query session.
+func (a *app) query(ctx context.Context, args []string) error {
+	const syntax = "query [-f file] <table> [query]"
+
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	path := fs.String("f", "", "")
+	if err := fs.Parse(args); err != nil || fs.NArg() < 1 || fs.NArg() > 2 || (*path != "" && fs.NArg() == 2) {
+		return usage(syntax)
+	}
+	table, err := a.table(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// Run a single query from the argument or file.
+	switch {
+	case fs.NArg() == 2:
+		return a.runQuery(ctx, a.stdout, table, fs.Arg(1))
+	case *path != "":
+		var b []byte
+		if *path == "-" {
+			b, err = io.ReadAll(a.stdin)
+		} else {
+			b, err = os.ReadFile(*path)
+		}
+		if err != nil {
+			return err
+		}
+		return a.runQuery(ctx, a.stdout, table, string(b))
+	}
+
+	r := &repl{app: a, ctx: ctx, table: table}
+	if err := r.load(); err != nil {
+		return err
+	}
+
+	// Use a line editor with history and completion on a terminal. Other
+	// input is read line by line without prompts.
+	if f, ok := a.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		state, err := term.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return err
+		}
+		defer term.Restore(int(f.Fd()), state)
+
+		t := term.NewTerminal(struct {
+			io.Reader
+			io.Writer
+		}{f, a.stdout}, "")
+		t.History = newFileHistory()
+		t.AutoCompleteCallback = r.complete
+		r.out = t
+		return r.run(t.SetPrompt, t.ReadLine)
+	}
+
+	r.out = a.stdout
+	scanner := bufio.NewScanner(a.stdin)
+	scanner.Buffer(nil, 16*1024*1024)
+	return r.run(func(string) {}, func() (string, error) {
+		if scanner.Scan() {
+			return scanner.Text(), nil
+		} else if err := scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	})
+}
+
+// runQuery parses and executes a raw JSON query and prints the results.
+func (a *app) runQuery(ctx context.Context, w io.Writer, table sky.Table, query string) error {
+	q := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(query), &q); err != nil {
+		return fmt.Errorf("invalid query: %v", err)
+	}
+	results, err := table.RawQueryContext(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	selections := pivotSelections(q["steps"])
+	if a.format == JSONFormat || len(selections) == 0 {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+	for i, s := range selections {
+		if len(selections) > 1 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s:\n", s.title(i))
+		}
+		if err := s.print(w, results); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//--------------------------------------
+// REPL
+//--------------------------------------
+
+// load retrieves the table names and the current table's properties for
+// completion.
+func (r *repl) load() error {
+	tables, err := r.table.Client().GetTablesContext(r.ctx)
+	if err != nil {
+		return err
+	}
+	r.tables = r.tables[:0]
+	for _, t := range tables {
+		r.tables = append(r.tables, t.Name())
+	}
+	r.properties, err = r.table.GetPropertiesContext(r.ctx)
+	return err
+}
+
+// run reads commands and queries until the input ends. Queries may span
+// several lines and are run once they form a complete JSON object.
+func (r *repl) run(setPrompt func(string), readLine func() (string, error)) error {
+	var buffer strings.Builder
+	for {
+		if buffer.Len() == 0 {
+			setPrompt(r.table.Name() + "> ")
+		} else {
+			setPrompt(strings.Repeat(" ", len(r.table.Name())-1) + "... ")
+		}
+		line, err := readLine()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// Handle commands between queries.
+		if buffer.Len() == 0 {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			} else if strings.HasPrefix(line, `\`) {
+				if quit := r.command(line); quit {
+					return nil
+				}
+				continue
+			}
+		}
+
+		buffer.WriteString(line)
+		buffer.WriteString("\n")
+		var q map[string]interface{}
+		if err := json.NewDecoder(strings.NewReader(buffer.String())).Decode(&q); err == io.ErrUnexpectedEOF {
+			continue
+		}
+		if err := r.app.runQuery(r.ctx, r.out, r.table, buffer.String()); err != nil {
+			fmt.Fprintf(r.out, "error: %v\n", err)
+		}
+		buffer.Reset()
+	}
+}
+
+// command runs a REPL command and returns true if the session should end.
+func (r *repl) command(line string) bool {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case `\q`, `\quit`:
+		return true
+
+	case `\table`:
+		if len(fields) != 2 {
+			fmt.Fprintf(r.out, "current table: %s\n", r.table.Name())
+			return false
+		}
+		previous := r.table
+		r.table = sky.NewTable(fields[1], previous.Client())
+		if err := r.load(); err != nil {
+			fmt.Fprintf(r.out, "error: %v\n", err)
+			r.table = previous
+			r.load()
+		}
+
+	case `\properties`:
+		w := tabwriter.NewWriter(r.out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tTYPE\tTRANSIENT")
+		for _, p := range r.properties {
+			fmt.Fprintf(w, "%s\t%s\t%v\n", p.Name, p.DataType, p.Transient)
+		}
+		w.Flush()
+
+	case `\format`:
+		if len(fields) == 2 && (fields[1] == TableFormat || fields[1] == JSONFormat) {
+			r.app.format = fields[1]
+		} else {
+			fmt.Fprintf(r.out, "format: %s (use \\format table|json)\n", r.app.format)
+		}
+
+	case `\help`:
+		fmt.Fprintln(r.out, `Enter a JSON query such as {"steps": [{"type": "selection", "fields": [{"name": "count", "expression": "count()"}]}]}`)
+		fmt.Fprintln(r.out, `\table <name>   switch tables`)
+		fmt.Fprintln(r.out, `\properties     list the table's properties`)
+		fmt.Fprintln(r.out, `\format <fmt>   print results as a pivot table or json`)
+		fmt.Fprintln(r.out, `\quit           exit`)
+
+	default:
+		fmt.Fprintf(r.out, "unknown command: %s (try \\help)\n", fields[0])
+	}
+	return false
+}
+
+// complete completes the word before the cursor on tab using table names,
+// commands, properties or query keywords.
+func (r *repl) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	start := pos
+	for start > 0 && isWordChar(line[start-1]) {
+		start--
+	}
+	word := line[start:pos]
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(line, `\table `):
+		candidates = r.tables
+	case strings.HasPrefix(word, `\`):
+		candidates = replCommands
+	case strings.HasPrefix(line, `\`):
+		return "", 0, false
+	default:
+		for _, p := range r.properties {
+			candidates = append(candidates, p.Name)
+		}
+		candidates = append(candidates, queryKeywords...)
+	}
+
+	completion := commonPrefix(word, candidates)
+	if len(completion) <= len(word) {
+		return "", 0, false
+	}
+	return line[:start] + completion + line[pos:], start + len(completion), true
+}
+
+//--------------------------------------
+// Pivot
+//--------------------------------------
+
+// Returns the selection's name, or its position if it is unnamed.
+func (s *pivotSelection) title(index int) string {
+	if s.name != "" {
+		return s.name
+	}
+	return fmt.Sprintf("selection %d", index+1)
+}
+
+// Prints the selection's results as a table with a column per dimension
+// and field.
+func (s *pivotSelection) print(w io.Writer, results map[string]interface{}) error {
+	if s.name != "" {
+		results, _ = results[s.name].(map[string]interface{})
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(append(append([]string{}, s.dimensions...), s.fields...), "\t"))
+	for _, row := range s.rows(results, 0, nil) {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// rows flattens nested dimension results into rows sorted by dimension
+// value.
+func (s *pivotSelection) rows(results map[string]interface{}, depth int, prefix []string) [][]string {
+	if results == nil {
+		return nil
+	}
+	if depth == len(s.dimensions) {
+		row := append([]string{}, prefix...)
+		for _, name := range s.fields {
+			row = append(row, formatResult(results[name]))
+		}
+		return [][]string{row}
+	}
+
+	values, _ := results[s.dimensions[depth]].(map[string]interface{})
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return lessKey(keys[i], keys[j]) })
+
+	var rows [][]string
+	for _, key := range keys {
+		child, _ := values[key].(map[string]interface{})
+		rows = append(rows, s.rows(child, depth+1, append(prefix, key))...)
+	}
+	return rows
+}
+
+//--------------------------------------
+// History
+//--------------------------------------
+
+// Adds an entry and appends it to the history file.
+func (h *fileHistory) Add(entry string) {
+	if strings.TrimSpace(entry) == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry) {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	if h.path == "" {
+		return
+	}
+	if f, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600); err == nil {
+		fmt.Fprintln(f, entry)
+		f.Close()
+	}
+}
+
+func (h *fileHistory) Len() int {
+	return len(h.entries)
+}
+
+// Returns an entry where index 0 is the most recent.
+func (h *fileHistory) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// newFileHistory loads the history from ~/.sky_history.
+func newFileHistory() *fileHistory {
+	h := &fileHistory{}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return h
+	}
+	h.path = filepath.Join(home, ".sky_history")
+	if b, err := os.ReadFile(h.path); err == nil {
+		for _, line := range strings.Split(string(b), "\n") {
+			if line != "" {
+				h.entries = append(h.entries, line)
+			}
+		}
+		if len(h.entries) > maxHistory {
+			h.entries = h.entries[len(h.entries)-maxHistory:]
+		}
+	}
+	return h
+}
+
+// pivotSelections finds the selections within raw query steps.
+func pivotSelections(steps interface{}) []*pivotSelection {
+	var selections []*pivotSelection
+	list, _ := steps.([]interface{})
+	for _, step := range list {
+		obj, _ := step.(map[string]interface{})
+		switch obj["type"] {
+		case "selection":
+			s := &pivotSelection{}
+			s.name, _ = obj["name"].(string)
+			dimensions, _ := obj["dimensions"].([]interface{})
+			for _, d := range dimensions {
+				if name, ok := d.(string); ok {
+					s.dimensions = append(s.dimensions, name)
+				}
+			}
+			fields, _ := obj["fields"].([]interface{})
+			for _, f := range fields {
+				if field, ok := f.(map[string]interface{}); ok {
+					if name, ok := field["name"].(string); ok {
+						s.fields = append(s.fields, name)
+					}
+				}
+			}
+			selections = append(selections, s)
+		case "condition":
+			selections = append(selections, pivotSelections(obj["steps"])...)
+		}
+	}
+	return selections
+}
+
+// formatResult formats a field result for a pivot table.
+func formatResult(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// lessKey orders dimension keys numerically when both are numbers.
+func lessKey(a, b string) bool {
+	x, errX := strconv.ParseFloat(a, 64)
+	y, errY := strconv.ParseFloat(b, 64)
+	if errX == nil && errY == nil {
+		return x < y
+	}
+	return a < b
+}
+
+// commonPrefix returns the longest common prefix of the candidates that
+// start with word.
+func commonPrefix(word string, candidates []string) string {
+	prefix, found := "", false
+	for _, c := range candidates {
+		if !strings.HasPrefix(c, word) {
+			continue
+		}
+		if !found {
+			prefix, found = c, true
+			continue
+		}
+		for !strings.HasPrefix(c, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '\\' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
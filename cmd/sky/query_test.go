@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky"
+	"github.com/snormore/gosky/skytest"
+)
+
+const testQuery = `{"steps": [{"type": "selection", "dimensions": ["name"], "fields": [{"name": "count", "expression": "count()"}, {"name": "total", "expression": "sum(age)"}]}]}`
+
+// newQueryServer starts a server with events to query.
+func newQueryServer(t *testing.T) *skytest.Server {
+	server, table := newImportServer(t)
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	table.AddEvent("u1", sky.NewEvent(t0, map[string]interface{}{"name": "bob", "age": 10}), sky.Replace)
+	table.AddEvent("u1", sky.NewEvent(t0.Add(time.Hour), map[string]interface{}{"name": "bob", "age": 5}), sky.Replace)
+	table.AddEvent("u2", sky.NewEvent(t0, map[string]interface{}{"name": "alice", "age": 3}), sky.Replace)
+	return server
+}
+
+// Ensure that a query argument is run and printed as a pivot table or JSON.
+func TestQuery(t *testing.T) {
+	server := newQueryServer(t)
+	defer server.Close()
+
+	code, stdout, stderr := execute(server, "query", "users", testQuery)
+	if code != 0 || stdout != "name   count  total\nalice  1      3\nbob    2      15\n" {
+		t.Fatalf("Unexpected pivot: %d %q %s", code, stdout, stderr)
+	}
+
+	code, stdout, _ = executeInput(server, testQuery, "-format", "json", "query", "-f", "-", "users")
+	if code != 0 || !strings.Contains(stdout, `"bob": {`) {
+		t.Fatalf("Unexpected JSON: %d %q", code, stdout)
+	}
+
+	if code, _, stderr := execute(server, "query", "users", "{"); code != 1 || !strings.Contains(stderr, "invalid query") {
+		t.Fatalf("Expected invalid query: %d %s", code, stderr)
+	}
+}
+
+// Ensure that a session runs multi-line queries and commands.
+func TestQuerySession(t *testing.T) {
+	server := newQueryServer(t)
+	defer server.Close()
+	execute(server, "tables", "create", "other")
+
+	input := `{"steps": [
+  {"type": "selection", "name": "totals", "fields": [{"name": "count", "expression": "count()"}]}
+]}
+\properties
+\table missing
+\table other
+\format json
+{"steps": [{"type": "selection", "fields": [{"name": "count", "expression": "count()"}]}]}
+{"steps": [{"type": "selection", "fields": [{"name": "x", "expression": "sum(nope)"}]}]}
+\bogus
+\quit
+{"steps": []}
+`
+	code, stdout, stderr := executeInput(server, input, "query", "users")
+	if code != 0 {
+		t.Fatalf("Unexpected session failure: %d %s", code, stderr)
+	}
+	for _, expected := range []string{
+		"count\n3\n",
+		"NAME    TYPE     TRANSIENT\nactive  boolean  true\n",
+		"error: sky.Error",
+		"{}\n",
+		"unknown command: \\bogus",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Fatalf("Expected %q in output: %s", expected, stdout)
+		}
+	}
+	if strings.Count(stdout, "error:") != 2 {
+		t.Fatalf("Expected two errors: %s", stdout)
+	}
+}
+
+// Ensure that tables, commands, properties and keywords are completed.
+func TestQueryComplete(t *testing.T) {
+	r := &repl{tables: []string{"users", "events"}, properties: []*sky.Property{sky.NewProperty("action", false, sky.Factor), sky.NewProperty("age", false, sky.Integer)}}
+	for _, test := range []struct {
+		line     string
+		expected string
+	}{
+		{`\table us`, `\table users`},
+		{`\pr`, `\properties`},
+		{`{"expression": "sum(ac`, `{"expression": "sum(action`},
+		{`{"expression": "a`, `{"expression": "a`},
+		{`{"dim`, `{"dimensions`},
+		{`{"sessionIdleTime": 1, "fi`, `{"sessionIdleTime": 1, "fields`},
+	} {
+		line, pos, ok := r.complete(test.line, len(test.line), '\t')
+		if !ok {
+			line, pos = test.line, len(test.line)
+		}
+		if line != test.expected || pos != len(test.expected) {
+			t.Fatalf("Unexpected completion of %q: %q %d", test.line, line, pos)
+		}
+	}
+	if _, _, ok := r.complete("x", 1, 'a'); ok {
+		t.Fatalf("Expected completion only on tab")
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"strconv"
+
+	"github.com/snormore/gosky"
+)
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+//--------------------------------------
+// Tables
+//--------------------------------------
+
+// Lists, creates and deletes tables.
+func (a *app) tables(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usage("tables list|create|delete")
+	}
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return usage("tables list")
+		}
+		client, err := a.connect()
+		if err != nil {
+			return err
+		}
+		tables, err := client.GetTablesContext(ctx)
+		if err != nil {
+			return err
+		}
+		names := make([]map[string]string, 0, len(tables))
+		rows := make([][]string, 0, len(tables))
+		for _, t := range tables {
+			names = append(names, map[string]string{"name": t.Name()})
+			rows = append(rows, []string{t.Name()})
+		}
+		return a.print(names, []string{"NAME"}, rows)
+
+	case "create", "delete":
+		if len(args) != 2 {
+			return usage("tables %s <table>", args[0])
+		}
+		client, err := a.connect()
+		if err != nil {
+			return err
+		}
+		if args[0] == "create" {
+			return client.CreateTableContext(ctx, sky.NewTable(args[1], nil))
+		}
+		return client.DeleteTableContext(ctx, sky.NewTable(args[1], nil))
+	}
+	return usage("tables list|create|delete")
+}
+
+//--------------------------------------
+// Properties
+//--------------------------------------
+
+// Lists, creates, renames and deletes properties.
+func (a *app) properties(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usage("properties list|create|update|delete")
+	}
+	switch args[0] {
+	case "list":
+		if len(args) != 2 {
+			return usage("properties list <table>")
+		}
+		table, err := a.table(args[1])
+		if err != nil {
+			return err
+		}
+		properties, err := table.GetPropertiesContext(ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(properties))
+		for _, p := range properties {
+			rows = append(rows, []string{strconv.Itoa(p.Id), p.Name, p.DataType, strconv.FormatBool(p.Transient)})
+		}
+		return a.print(properties, []string{"ID", "NAME", "TYPE", "TRANSIENT"}, rows)
+
+	case "create":
+		fs := flag.NewFlagSet("properties create", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		transient := fs.Bool("transient", false, "")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 3 {
+			return usage("properties create [-transient] <table> <name> <type>")
+		}
+		switch fs.Arg(2) {
+		case sky.String, sky.Integer, sky.Float, sky.Boolean, sky.Factor:
+		default:
+			return usage("properties create [-transient] <table> <name> string|integer|float|boolean|factor")
+		}
+		table, err := a.table(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		return table.CreatePropertyContext(ctx, sky.NewProperty(fs.Arg(1), *transient, fs.Arg(2)))
+
+	case "update":
+		if len(args) != 4 {
+			return usage("properties update <table> <name> <new name>")
+		}
+		table, err := a.table(args[1])
+		if err != nil {
+			return err
+		}
+		property, err := table.GetPropertyContext(ctx, args[2])
+		if err != nil {
+			return err
+		}
+		property.Name = args[3]
+		return table.UpdatePropertyContext(ctx, args[2], property)
+
+	case "delete":
+		if len(args) != 3 {
+			return usage("properties delete <table> <name>")
+		}
+		table, err := a.table(args[1])
+		if err != nil {
+			return err
+		}
+		return table.DeletePropertyContext(ctx, sky.NewProperty(args[2], false, ""))
+	}
+	return usage("properties list|create|update|delete")
+}
+
+//--------------------------------------
+// Stats
+//--------------------------------------
+
+// Shows table statistics.
+func (a *app) stats(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return usage("stats <table>")
+	}
+	table, err := a.table(args[0])
+	if err != nil {
+		return err
+	}
+	stats, err := table.StatsContext(ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(stats, []string{"COUNT"}, [][]string{{strconv.Itoa(stats.Count)}})
+}
@@ -1,14 +1,53 @@
 package sky
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+//------------------------------------------------------------------------------
+//
+// Errors
+//
+//------------------------------------------------------------------------------
+
+// Sentinel errors that an *Error matches with errors.Is based on its status
+// code or transport failure.
+var (
+	ErrBadRequest   = errors.New("sky: bad request")
+	ErrUnauthorized = errors.New("sky: unauthorized")
+	ErrNotFound     = errors.New("sky: not found")
+	ErrConflict     = errors.New("sky: conflict")
+	ErrUnavailable  = errors.New("sky: server unavailable")
+)
+
+// ErrUnsupported is returned when an operation needs an endpoint that the
+// server doesn't have and that can't be emulated with other endpoints.
+var ErrUnsupported = errors.New("sky: unsupported by server")
+
 //------------------------------------------------------------------------------
 //
 // Typedefs
 //
 //------------------------------------------------------------------------------
 
-// An error generated from the Sky server.
+// An error generated from the Sky server or from failing to reach it.
 type Error struct {
-	message string
+	// The HTTP status code returned by the server. Zero if the request
+	// failed before a response was received.
+	StatusCode int
+
+	// The request that failed.
+	Method string
+	URL    string
+
+	// The message returned by the server, if any.
+	Message string
+
+	// The underlying transport error, if any.
+	Err error
 }
 
 //------------------------------------------------------------------------------
@@ -19,7 +58,7 @@ type Error struct {
 
 // NewError creates a new Sky error object.
 func NewError(message string) *Error {
-	return &Error{message: message}
+	return &Error{Message: message}
 }
 
 //------------------------------------------------------------------------------
@@ -30,5 +69,75 @@ func NewError(message string) *Error {
 
 // The error message.
 func (e *Error) Error() string {
-	return e.message
+	switch {
+	case e.Method == "":
+		return e.Message
+	case e.Err != nil:
+		return fmt.Sprintf("sky.Error: \"%s %s\": %v", e.Method, e.URL, e.Err)
+	case e.Message != "":
+		return fmt.Sprintf("sky.Error: \"%s %s\" [%d]: %s", e.Method, e.URL, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("sky.Error: \"%s %s\" [%d]", e.Method, e.URL, e.StatusCode)
+}
+
+// The underlying transport error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Matches the error against the sentinel errors.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrUnavailable:
+		switch e.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		case 0:
+			return e.Err != nil && !errors.Is(e.Err, context.Canceled) && !errors.Is(e.Err, context.DeadlineExceeded)
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// IsNotFound returns true if the error means the table, property or event
+// does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsUnauthorized returns true if the server rejected the client's
+// credentials.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsConflict returns true if the error means the table or property already
+// exists.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// IsUnsupported returns true if the server doesn't support the operation.
+func IsUnsupported(err error) bool {
+	return errors.Is(err, ErrUnsupported)
+}
+
+// IsUnavailable returns true if the server could not be reached or is
+// temporarily unable to handle the request.
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrUnavailable)
 }
@@ -0,0 +1,78 @@
+package sky
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+// Ensure that server errors carry the status code and message.
+func TestErrorNotFound(t *testing.T) {
+	run(t, func(client Client, _ Table) {
+		_, err := client.GetTable("sky-go-missing")
+		var e *Error
+		if !errors.As(err, &e) || e.StatusCode != http.StatusNotFound || e.Method != "GET" || e.Message == "" {
+			t.Fatalf("Expected not found error: %#v", err)
+		}
+		if !IsNotFound(err) || IsConflict(err) || IsUnavailable(err) {
+			t.Fatalf("Invalid error classification: %v", err)
+		}
+	})
+}
+
+// Ensure that creating an existing table is a conflict.
+func TestErrorConflict(t *testing.T) {
+	run(t, func(client Client, _ Table) {
+		if err := client.CreateTable(NewTable(testTableName, nil)); !IsConflict(err) {
+			t.Fatalf("Expected conflict error: %v", err)
+		}
+	})
+}
+
+// Ensure that an unreachable server is unavailable.
+func TestErrorUnavailable(t *testing.T) {
+	server := skytest.NewServer()
+	client := NewClientEx(server.Host(), server.Port())
+	server.Close()
+
+	_, err := client.GetTables()
+	if !IsUnavailable(err) || IsNotFound(err) {
+		t.Fatalf("Expected unavailable error: %v", err)
+	}
+}
+
+// Ensure that a stream to an unreachable server is unavailable.
+func TestErrorStreamUnavailable(t *testing.T) {
+	server := skytest.NewServer()
+	client := NewClientEx(server.Host(), server.Port())
+	server.Close()
+
+	_, err := NewTableEventStream(client, NewTable(testTableName, client))
+	var e *Error
+	if !errors.As(err, &e) || e.Method != "PATCH" || e.URL != client.URL("/tables/"+testTableName+"/events") {
+		t.Fatalf("Expected stream error: %#v", err)
+	}
+	if !IsUnavailable(err) || IsNotFound(err) {
+		t.Fatalf("Expected unavailable error: %v", err)
+	}
+}
+
+// Ensure that a stream rejected by the server returns the server's error.
+func TestErrorStream(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		stream, err := table.Stream()
+		if err != nil {
+			t.Fatalf("Failed to create event stream: (%v)", err)
+		}
+		stream.AddEvent("xyz", NewEvent(time.Now(), map[string]interface{}{"no_such_property": 1}))
+
+		err = stream.Close()
+		var e *Error
+		if !errors.As(err, &e) || !errors.Is(err, ErrBadRequest) || e.Message == "" {
+			t.Fatalf("Expected bad request error: %#v", err)
+		}
+	})
+}
@@ -0,0 +1,309 @@
+package sky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// EventOptions bounds and orders the events returned by an event iterator.
+type EventOptions struct {
+	// Only events at or after Start and before End are returned. Zero
+	// values are unbounded.
+	Start time.Time
+	End   time.Time
+
+	// The maximum number of events to return. Zero is unlimited.
+	Limit int
+
+	// Returns events from newest to oldest.
+	Reverse bool
+}
+
+// An EventIterator reads an object's events one at a time as they are
+// decoded from the server's response, so memory use does not grow with the
+// object's history. It must be closed when it is no longer needed.
+//
+// Bounds are also applied by the iterator in case the server ignores them.
+// If the server ignores a reverse order then only the last Limit events
+// within the bounds are kept and reversed in memory. Without a limit the
+// iteration fails with an error matching ErrUnsupported instead.
+//
+//	it, err := table.Events("john", &sky.EventOptions{Limit: 100})
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		fmt.Println(it.Event().Timestamp)
+//	}
+//	return it.Err()
+type EventIterator struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+	options EventOptions
+	event   *Event
+	last    *Event
+	buffer  []*Event
+	ordered bool
+	eof     bool
+	count   int
+	err     error
+	done    bool
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// Retrieves an iterator over an object's events.
+func (t *table) Events(objectId string, options *EventOptions) (*EventIterator, error) {
+	return t.EventsContext(context.Background(), objectId, options)
+}
+
+// Retrieves an iterator over an object's events using the given context. The
+// context applies until the iterator is closed. Nil options return every
+// event in order.
+func (t *table) EventsContext(ctx context.Context, objectId string, options *EventOptions) (*EventIterator, error) {
+	if t.client == nil {
+		return nil, errors.New("Table is not attached to a client")
+	}
+	if objectId == "" {
+		return nil, errors.New("Object identifier required")
+	}
+	it := &EventIterator{}
+	if options != nil {
+		it.options = *options
+	}
+
+	// Pass the bounds to the server so it can skip events outside them. The
+	// limit is only passed without other bounds since a server that applies
+	// the limit but ignores the rest would return too few events.
+	query := url.Values{}
+	if !it.options.Start.IsZero() {
+		query.Set("start", FormatTimestamp(it.options.Start))
+	}
+	if !it.options.End.IsZero() {
+		query.Set("end", FormatTimestamp(it.options.End))
+	}
+	if it.options.Limit > 0 && len(query) == 0 && !it.options.Reverse {
+		query.Set("limit", strconv.Itoa(it.options.Limit))
+	}
+	if it.options.Reverse {
+		query.Set("order", "desc")
+	}
+	path := fmt.Sprintf("/tables/%s/objects/%s/events", t.name, objectId)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	if err := t.client.SendContext(ctx, "GET", path, nil, &it.body); err != nil {
+		return nil, err
+	} else if it.body == nil {
+		return nil, errors.New("sky.EventIterator: Client does not support streamed responses")
+	}
+
+	// Read the opening bracket of the event array.
+	it.decoder = json.NewDecoder(it.body)
+	if tok, err := it.decoder.Token(); err != nil || tok != json.Delim('[') {
+		it.body.Close()
+		if err == nil {
+			err = fmt.Errorf("sky.EventIterator: Expected event array: %v", tok)
+		}
+		return nil, err
+	}
+	return it, nil
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// Advances to the next event. It returns false when there are no more
+// events or an error occurred.
+func (it *EventIterator) Next() bool {
+	if it.done {
+		return false
+	}
+	if it.options.Limit > 0 && it.count >= it.options.Limit {
+		return it.finish(nil)
+	}
+	if it.options.Reverse && !it.ordered {
+		it.ordered = true
+		if err := it.order(); err != nil {
+			return it.finish(err)
+		}
+	}
+
+	for {
+		event, err := it.read()
+		if err != nil {
+			return it.finish(err)
+		} else if event == nil {
+			return it.finish(nil)
+		}
+
+		// Events must arrive in the requested order for the bounds to end
+		// the iteration early.
+		if it.last != nil && it.outOfOrder(it.last.Timestamp, event.Timestamp) {
+			return it.finish(errors.New("sky.EventIterator: Events out of order"))
+		}
+		it.last = event
+
+		switch {
+		case !it.before(event.Timestamp):
+			// Past the end of the range.
+			return it.finish(nil)
+		case !it.after(event.Timestamp):
+			// Not yet in the range.
+			continue
+		}
+		it.event = event
+		it.count++
+		return true
+	}
+}
+
+// Retrieves the current event.
+func (it *EventIterator) Event() *Event {
+	return it.event
+}
+
+// Retrieves the error that stopped the iteration, if any.
+func (it *EventIterator) Err() error {
+	return it.err
+}
+
+// Closes the response. Any remaining events are discarded.
+func (it *EventIterator) Close() error {
+	it.done = true
+	return it.body.Close()
+}
+
+// read returns the next event from the read-ahead buffer or the response. It
+// returns nil after the last event.
+func (it *EventIterator) read() (*Event, error) {
+	if len(it.buffer) > 0 {
+		event := it.buffer[0]
+		it.buffer = it.buffer[1:]
+		return event, nil
+	} else if it.eof {
+		return nil, nil
+	}
+	event, err := it.decode()
+	if event == nil && err == nil {
+		it.eof = true
+	}
+	return event, err
+}
+
+// decode decodes the next event from the response. It returns nil once the
+// closing bracket of the event array is read.
+func (it *EventIterator) decode() (*Event, error) {
+	if !it.decoder.More() {
+		_, err := it.decoder.Token()
+		return nil, err
+	}
+	obj := map[string]interface{}{}
+	if err := it.decoder.Decode(&obj); err != nil {
+		return nil, err
+	}
+	event := &Event{}
+	if err := event.Deserialize(obj); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// order reads ahead to the first two distinct timestamps to check that the
+// server returned events in reverse. If they are ascending instead then the
+// server ignored the order, so the last Limit events within the bounds are
+// kept and reversed.
+func (it *EventIterator) order() error {
+	for !it.eof && (len(it.buffer) == 0 || it.buffer[0].Timestamp.Equal(it.buffer[len(it.buffer)-1].Timestamp)) {
+		event, err := it.decode()
+		if err != nil {
+			return err
+		} else if event == nil {
+			it.eof = true
+		} else {
+			it.buffer = append(it.buffer, event)
+		}
+	}
+	if len(it.buffer) == 0 || !it.buffer[len(it.buffer)-1].Timestamp.After(it.buffer[0].Timestamp) {
+		return nil
+	} else if it.options.Limit <= 0 {
+		return fmt.Errorf("sky.EventIterator: Reverse order requires a limit: %w", ErrUnsupported)
+	}
+
+	var events []*Event
+	for {
+		event, err := it.read()
+		if err != nil {
+			return err
+		} else if event == nil {
+			break
+		}
+		if it.before(event.Timestamp) && it.after(event.Timestamp) {
+			events = append(events, event)
+			if len(events) > it.options.Limit {
+				events = events[1:]
+			}
+		}
+	}
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	it.buffer = events
+	return nil
+}
+
+// outOfOrder returns true if a timestamp shouldn't follow the previous one in
+// the iteration order.
+func (it *EventIterator) outOfOrder(prev time.Time, timestamp time.Time) bool {
+	if it.options.Reverse {
+		return timestamp.After(prev)
+	}
+	return timestamp.Before(prev)
+}
+
+// before returns true if a timestamp hasn't passed the far bound of the
+// range in the iteration order.
+func (it *EventIterator) before(timestamp time.Time) bool {
+	if it.options.Reverse {
+		return it.options.Start.IsZero() || !timestamp.Before(it.options.Start)
+	}
+	return it.options.End.IsZero() || timestamp.Before(it.options.End)
+}
+
+// after returns true if a timestamp has reached the near bound of the range
+// in the iteration order.
+func (it *EventIterator) after(timestamp time.Time) bool {
+	if it.options.Reverse {
+		return it.options.End.IsZero() || timestamp.Before(it.options.End)
+	}
+	return it.options.Start.IsZero() || !timestamp.Before(it.options.Start)
+}
+
+// finish ends the iteration with an optional error and releases the response.
+func (it *EventIterator) finish(err error) bool {
+	it.done, it.err = true, err
+	it.event = nil
+	it.body.Close()
+	return false
+}
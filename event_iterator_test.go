@@ -0,0 +1,101 @@
+package sky
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Ensure that an object's events can be iterated within bounds and in either
+// order.
+func TestEventIterator(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		now := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 10; i++ {
+			if err := table.AddEvent("xyz", NewEvent(now.Add(time.Duration(i)*time.Hour), nil), Replace); err != nil {
+				t.Fatalf("Unable to add event #%d: %v", i, err)
+			}
+		}
+
+		tests := []struct {
+			options *EventOptions
+			hours   string
+		}{
+			{nil, "0,1,2,3,4,5,6,7,8,9"},
+			{&EventOptions{Start: now.Add(2 * time.Hour), End: now.Add(5 * time.Hour)}, "2,3,4"},
+			{&EventOptions{Start: now.Add(2 * time.Hour), Limit: 2}, "2,3"},
+			{&EventOptions{Reverse: true, End: now.Add(5 * time.Hour), Limit: 3}, "4,3,2"},
+		}
+		for _, test := range tests {
+			if hours := iterateHours(t, table, test.options, now); hours != test.hours {
+				t.Fatalf("Unexpected events for %+v: %s", test.options, hours)
+			}
+		}
+	})
+}
+
+// Ensure that bounds and order are applied by the client when the server
+// ignores them.
+func TestEventIteratorClientBounds(t *testing.T) {
+	// The server applies a limit but ignores the other bounds and the order.
+	now := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		var events []string
+		for i := 0; i < 10 && (limit == 0 || i < limit); i++ {
+			events = append(events, fmt.Sprintf(`{"timestamp":%q,"data":{}}`, FormatTimestamp(now.Add(time.Duration(i)*time.Hour))))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(events, ","))
+	}))
+	defer server.Close()
+
+	client, _ := NewClientFromURL(server.URL)
+	table := NewTable(testTableName, client)
+	tests := []struct {
+		options *EventOptions
+		hours   string
+	}{
+		{&EventOptions{Limit: 3}, "0,1,2"},
+		{&EventOptions{Start: now.Add(7 * time.Hour), Limit: 2}, "7,8"},
+		{&EventOptions{Reverse: true, Limit: 20}, "9,8,7,6,5,4,3,2,1,0"},
+		{&EventOptions{Reverse: true, End: now.Add(5 * time.Hour), Limit: 3}, "4,3,2"},
+		{&EventOptions{Reverse: true, Start: now.Add(8 * time.Hour), Limit: 5}, "9,8"},
+	}
+	for _, test := range tests {
+		if hours := iterateHours(t, table, test.options, now); hours != test.hours {
+			t.Fatalf("Unexpected events for %+v: %s", test.options, hours)
+		}
+	}
+
+	// Reversing every event would hold the whole history in memory.
+	it, err := table.Events("xyz", &EventOptions{Reverse: true})
+	if err != nil {
+		t.Fatalf("Unable to iterate: %v", err)
+	}
+	defer it.Close()
+	if it.Next() || !IsUnsupported(it.Err()) {
+		t.Fatalf("Expected unsupported error: %v", it.Err())
+	}
+}
+
+// iterateHours iterates an object's events and returns their hours after now.
+func iterateHours(t *testing.T, table Table, options *EventOptions, now time.Time) string {
+	it, err := table.Events("xyz", options)
+	if err != nil {
+		t.Fatalf("Unable to iterate: %v", err)
+	}
+	defer it.Close()
+
+	var hours []string
+	for it.Next() {
+		hours = append(hours, fmt.Sprint(int(it.Event().Timestamp.Sub(now).Hours())))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Iteration failed: %v", err)
+	}
+	return strings.Join(hours, ",")
+}
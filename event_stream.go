@@ -2,12 +2,15 @@ package sky
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
-	"strings"
+	"net/http"
+	"time"
 )
 
 //------------------------------------------------------------------------------
@@ -18,13 +21,30 @@ import (
 
 // An event stream maintains an open connection to the database to send events
 // in bulk. This is the base stream type.
+//
+// Events are acknowledged by the server when the stream is committed or
+// closed. If a reconnect policy is set then the stream keeps every event
+// since the last acknowledgement and, when a write fails, reconnects and
+// replays them. Since streamed events are merged, replaying an event that was
+// already received is harmless.
+//
+// If a spool is set then events that can't be delivered because the server
+// is unreachable are written to disk instead of being returned as
+// undelivered. While the spool holds events, new events are appended to it
+// and the stream periodically tries to drain it to the server in order.
 type Stream struct {
 	client  Client
-	header  []byte
-	encoder *json.Encoder
+	path    string
 	chunker *chunkWriter
 	buffer  *bufio.Writer
 	conn    net.Conn
+	policy  *ReconnectPolicy
+	pending []*pendingEvent
+	count   int
+	size    int
+
+	spool     *Spool
+	lastDrain time.Time
 }
 
 // EventStream is a table-less stream.
@@ -38,6 +58,42 @@ type TableEventStream struct {
 	table Table
 }
 
+// A ReconnectPolicy controls how a stream recovers from connection failures.
+type ReconnectPolicy struct {
+	// The maximum number of reconnection attempts after a failure.
+	MaxAttempts int
+
+	// The delay before the first attempt. The delay doubles on each
+	// following attempt, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// The number of events after which the stream commits automatically,
+	// which bounds the number of events held for replay. Zero disables
+	// automatic commits.
+	CommitEvery int
+}
+
+// An UndeliveredEvent is an event that a stream was unable to deliver.
+type UndeliveredEvent struct {
+	Table    string
+	ObjectId string
+	Event    *Event
+}
+
+// A StreamError is returned when a stream fails to recover from a connection
+// failure. It lists every event since the last acknowledgement. These events
+// are dropped from the stream and are the caller's responsibility.
+type StreamError struct {
+	Err         error
+	Undelivered []*UndeliveredEvent
+}
+
+type pendingEvent struct {
+	data  []byte
+	event *UndeliveredEvent
+}
+
 //------------------------------------------------------------------------------
 //
 // Constructor
@@ -45,15 +101,38 @@ type TableEventStream struct {
 //------------------------------------------------------------------------------
 
 func NewTableEventStream(c Client, table Table) (*TableEventStream, error) {
-	header := fmt.Sprintf("PATCH /tables/%s/events HTTP/1.0\r\nHost: %s\r\nContent-Type: application/json\r\nTransfer-Encoding: chunked\r\n\r\n", table.Name(), c.GetHost())
-	s := &TableEventStream{&Stream{client: c, header: []byte(header)}, table}
-	return s, s.Reconnect()
+	return NewTableEventStreamContext(context.Background(), c, table)
+}
+
+// NewTableEventStreamContext opens a table specific event stream. The context
+// only applies to establishing the connection.
+func NewTableEventStreamContext(ctx context.Context, c Client, table Table) (*TableEventStream, error) {
+	path := fmt.Sprintf("/tables/%s/events", table.Name())
+	s := &TableEventStream{&Stream{client: c, path: path}, table}
+	return s, s.ReconnectContext(ctx)
 }
 
 func NewEventStream(c Client) (*EventStream, error) {
-	header := fmt.Sprintf("PATCH /events HTTP/1.0\r\nHost: %s\r\nContent-Type: application/json\r\nTransfer-Encoding: chunked\r\n\r\n", c.GetHost())
-	s := &EventStream{&Stream{client: c, header: []byte(header)}}
-	return s, s.Reconnect()
+	return NewEventStreamContext(context.Background(), c)
+}
+
+// NewEventStreamContext opens a table agnostic event stream. The context only
+// applies to establishing the connection.
+func NewEventStreamContext(ctx context.Context, c Client) (*EventStream, error) {
+	path := "/events"
+	s := &EventStream{&Stream{client: c, path: path}}
+	return s, s.ReconnectContext(ctx)
+}
+
+// DefaultReconnectPolicy returns a policy that retries a few times over
+// several seconds and commits every 10,000 events.
+func DefaultReconnectPolicy() *ReconnectPolicy {
+	return &ReconnectPolicy{
+		MaxAttempts: 5,
+		Backoff:     100 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+		CommitEvery: 10000,
+	}
 }
 
 //------------------------------------------------------------------------------
@@ -68,6 +147,11 @@ func NewEventStream(c Client) (*EventStream, error) {
 
 // Adds an event to an object.
 func (s *TableEventStream) AddEvent(objectId string, event *Event) error {
+	return s.AddEventContext(context.Background(), objectId, event)
+}
+
+// Adds an event to an object using the given context.
+func (s *TableEventStream) AddEventContext(ctx context.Context, objectId string, event *Event) error {
 	if objectId == "" {
 		return errors.New("Object identifier required")
 	}
@@ -80,11 +164,16 @@ func (s *TableEventStream) AddEvent(objectId string, event *Event) error {
 	data["id"] = objectId
 
 	// Encode the serialized data into the stream.
-	return s.encoder.Encode(data)
+	return s.write(ctx, data, &UndeliveredEvent{Table: s.table.Name(), ObjectId: objectId, Event: event})
 }
 
 // Adds an event to an object.
 func (s *EventStream) AddEvent(table Table, objectId string, event *Event) error {
+	return s.AddEventContext(context.Background(), table, objectId, event)
+}
+
+// Adds an event to an object using the given context.
+func (s *EventStream) AddEventContext(ctx context.Context, table Table, objectId string, event *Event) error {
 	if objectId == "" {
 		return errors.New("Object identifier required")
 	}
@@ -101,20 +190,285 @@ func (s *EventStream) AddEvent(table Table, objectId string, event *Event) error
 	data["table"] = table.Name()
 
 	// Encode the serialized data into the stream.
-	return s.encoder.Encode(data)
+	return s.write(ctx, data, &UndeliveredEvent{Table: table.Name(), ObjectId: objectId, Event: event})
+}
+
+//--------------------------------------
+// Reconnection
+//--------------------------------------
+
+// Retrieves the stream's reconnect policy.
+func (s *Stream) ReconnectPolicy() *ReconnectPolicy {
+	return s.policy
+}
+
+// Sets the stream's reconnect policy. A nil policy disables automatic
+// reconnection, which is the default. The policy should be set before any
+// events are added since only events added afterward can be replayed.
+func (s *Stream) SetReconnectPolicy(policy *ReconnectPolicy) {
+	s.policy = policy
+	if !s.replayable() {
+		s.pending = nil
+	}
+}
+
+//--------------------------------------
+// Spooling
+//--------------------------------------
+
+// Retrieves the stream's spool.
+func (s *Stream) Spool() *Spool {
+	return s.spool
+}
+
+// Sets the spool that holds events while the server is unreachable. A nil
+// spool disables spooling, which is the default. Like the reconnect policy,
+// the spool should be set before any events are added. Events that the
+// previous user of the spool left behind are drained first.
+//
+// The caller remains responsible for closing the spool.
+func (s *Stream) SetSpool(spool *Spool) {
+	s.spool = spool
+	if !s.replayable() {
+		s.pending = nil
+	}
+}
+
+// Sends all spooled events to the server and reconnects the stream.
+func (s *Stream) Drain() error {
+	return s.DrainContext(context.Background())
+}
+
+// Sends all spooled events to the server and reconnects the stream using
+// the given context.
+func (s *Stream) DrainContext(ctx context.Context) error {
+	if s.spool == nil {
+		return nil
+	}
+	if err := s.drain(ctx); err != nil {
+		return err
+	}
+	return s.ReconnectContext(ctx)
+}
+
+// spooling returns true if events are currently written to the spool rather
+// than the connection.
+func (s *Stream) spooling() bool {
+	return s.spool != nil && (s.buffer == nil || !s.spool.Empty())
+}
+
+// drainIfDue drains the spool if the retry interval has passed since the last
+// attempt. Failing to reach the server is not an error since the events stay
+// in the spool.
+func (s *Stream) drainIfDue(ctx context.Context) error {
+	if time.Since(s.lastDrain) < s.spool.config.RetryInterval {
+		return nil
+	}
+	if err := s.DrainContext(ctx); err != nil && !spoolable(err) {
+		return err
+	}
+	return nil
+}
+
+// drain sends spooled events in order, committing one batch per request.
+// The spool's cursor is only advanced once the server acknowledges a batch.
+func (s *Stream) drain(ctx context.Context) error {
+	s.lastDrain = time.Now()
+	for !s.spool.Empty() {
+		data, next, err := s.spool.read(spoolBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(data) == 0 && next == s.spool.cursor {
+			return fmt.Errorf("sky.Spool: Unreadable segment: %d", next.Segment)
+		}
+
+		if len(data) > 0 {
+			if err := s.connect(ctx); err != nil {
+				return err
+			}
+			err := interruptible(ctx, s.conn, func() error {
+				if _, err := s.buffer.Write(data); err != nil {
+					return err
+				}
+				return s.close()
+			})
+			s.disconnect()
+			logMessage(ctx, s.client, err, "sky stream drain", "path", s.path, "bytes", len(data))
+			if err != nil {
+				return err
+			}
+		}
+		if err := s.spool.advance(next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spoolPending moves events awaiting acknowledgement into the spool.
+func (s *Stream) spoolPending() error {
+	for i, p := range s.pending {
+		if err := s.spool.append(p.data); err != nil {
+			s.pending = s.pending[i:]
+			return err
+		}
+	}
+	s.pending, s.count, s.size = nil, 0, 0
+	s.lastDrain = time.Now()
+	return nil
+}
+
+// Retrieves the number of events sent since the last acknowledgement that
+// would be replayed on reconnection.
+func (s *Stream) Pending() int {
+	return len(s.pending)
+}
+
+// Retrieves the number of events and bytes written since the last commit.
+func (s *Stream) Uncommitted() (count int, size int) {
+	return s.count, s.size
+}
+
+// Returns the delay before a given reconnection attempt.
+func (p *ReconnectPolicy) backoff(attempt int) time.Duration {
+	return backoff(p.Backoff, p.MaxBackoff, attempt)
 }
 
+//--------------------------------------
+// Connection management
+//--------------------------------------
+
 // Send any buffered events to the server
 func (s *Stream) Flush() error {
-	return s.buffer.Flush()
+	return s.FlushContext(context.Background())
+}
+
+// Send any buffered events to the server using the given context
+func (s *Stream) FlushContext(ctx context.Context) error {
+	attrs := s.spanAttributes()
+	if s.buffer != nil {
+		attrs["sky.flush.size"] = s.buffer.Buffered()
+	}
+	ctx, end := startSpan(ctx, s.client, "sky stream flush", attrs)
+	err := s.flush(ctx)
+	end(err)
+	return err
+}
+
+func (s *Stream) flush(ctx context.Context) error {
+	if s.spooling() {
+		return s.drainIfDue(ctx)
+	}
+	if s.buffer == nil {
+		return s.recover(ctx, errors.New("Stream is not connected"), new(int))
+	}
+	size := s.buffer.Buffered()
+	err := interruptible(ctx, s.conn, s.buffer.Flush)
+	logMessage(ctx, s.client, err, "sky stream flush", "path", s.path, "bytes", size)
+	if err != nil {
+		return s.recover(ctx, err, new(int))
+	}
+	return nil
+}
+
+// Commits all events sent so far and starts a new request. The server
+// acknowledges the events once they are committed so they no longer need to
+// be held for replay.
+func (s *Stream) Commit() error {
+	return s.CommitContext(context.Background())
+}
+
+// Commits all events sent so far using the given context.
+func (s *Stream) CommitContext(ctx context.Context) error {
+	if s.spooling() {
+		return s.drainIfDue(ctx)
+	}
+	if err := s.commit(ctx); err != nil {
+		return err
+	}
+	if err := s.ReconnectContext(ctx); err != nil {
+		if s.spool == nil || !spoolable(err) {
+			return err
+		}
+		s.lastDrain = time.Now()
+	}
+	return nil
 }
 
 // Close the event stream
 func (s *Stream) Close() error {
-	defer s.conn.Close()
+	return s.CloseContext(context.Background())
+}
+
+// Close the event stream using the given context. If events remain spooled
+// after a final attempt to drain them then an error wrapping ErrSpooled is
+// returned.
+func (s *Stream) CloseContext(ctx context.Context) error {
+	ctx, end := startSpan(ctx, s.client, "sky stream close", s.spanAttributes())
+	err := s.commit(ctx)
+	s.disconnect()
+	if err == nil && s.spool != nil && !s.spool.Empty() {
+		if cause := s.drain(ctx); cause != nil {
+			err = fmt.Errorf("%w (%d bytes): %v", ErrSpooled, s.spool.Size(), cause)
+		}
+	}
+	logMessage(ctx, s.client, err, "sky stream close", "path", s.path)
+	end(err)
+	return err
+}
+
+// commit ends the current request and waits for the server's response. If
+// the connection fails then the stream reconnects and replays its events
+// according to the reconnect policy.
+func (s *Stream) commit(ctx context.Context) error {
+	// Reconnects made while committing share one attempt budget.
+	var attempts int
+	refreshed := false
+	for {
+		var err error
+		if s.buffer == nil {
+			err = errors.New("Stream is not connected")
+		} else {
+			start := time.Now()
+			err = interruptible(ctx, s.conn, s.close)
+			logMessage(ctx, s.client, err, "sky stream commit", "path", s.path, "status", statusCode(err), "events", s.count, "bytes", s.size, "latency", time.Since(start))
+			if err == nil {
+				s.pending, s.count, s.size = nil, 0, 0
+				return nil
+			}
+			countStream(s.client, s.path, StreamCloseFailures, 1)
+		}
 
+		// Refresh rejected credentials and replay once, with or without a
+		// reconnect policy, the same as requests.
+		if refresher, ok := s.client.Authenticator().(Refresher); ok && !refreshed && IsUnauthorized(err) {
+			refreshed = true
+			if err := refresher.Refresh(ctx); err != nil {
+				return s.fail(ctx, err)
+			}
+			if err = s.ReconnectContext(ctx); err == nil {
+				continue
+			}
+		}
+
+		var e *Error
+		switch {
+		case s.policy == nil || attempts >= s.policy.MaxAttempts:
+			return s.fail(ctx, err)
+		case errors.As(err, &e):
+			// Any other response from the server can't be fixed by replaying.
+			return s.fail(ctx, err)
+		}
+		if err := s.recover(ctx, err, &attempts); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Stream) close() error {
 	// Flush any buffered events
-	if err := s.Flush(); err != nil {
+	if err := s.buffer.Flush(); err != nil {
 		return err
 	}
 
@@ -124,50 +478,301 @@ func (s *Stream) Close() error {
 	}
 
 	// Check server response status
-	reader := bufio.NewReader(s.conn)
-	status, err := reader.ReadString('\r')
+	resp, err := http.ReadResponse(bufio.NewReader(s.conn), nil)
 	if err != nil {
 		return err
 	}
-	if strings.HasPrefix(status, "HTTP/1.0 200") {
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
 		return nil
 	}
-	return errors.New(status)
+	return newResponseError("PATCH", s.client.URL(s.path), resp)
 }
 
-// Attempt to reconnect the event stream with the server
+// Attempt to reconnect the event stream with the server. Any events since the
+// last acknowledgement are replayed on the new connection.
 func (s *Stream) Reconnect() error {
+	return s.ReconnectContext(context.Background())
+}
 
-	// Close the existing connection
-	if s.conn != nil {
-		s.conn.Close()
-		s.conn = nil
+// Attempt to reconnect the event stream with the server using the given context
+func (s *Stream) ReconnectContext(ctx context.Context) error {
+	attrs := s.spanAttributes()
+	attrs["sky.events.replayed"] = len(s.pending)
+	ctx, end := startSpan(ctx, s.client, "sky stream reconnect", attrs)
+	start := time.Now()
+	err := s.reconnect(ctx)
+	logMessage(ctx, s.client, err, "sky stream connect", "path", s.path, "replayed", len(s.pending), "latency", time.Since(start))
+	end(err)
+	return err
+}
+
+func (s *Stream) reconnect(ctx context.Context) error {
+	if err := s.connect(ctx); err != nil {
+		return err
 	}
 
+	// Replay unacknowledged events.
+	if len(s.pending) == 0 {
+		return nil
+	}
+	return interruptible(ctx, s.conn, func() error {
+		for _, p := range s.pending {
+			if _, err := s.buffer.Write(p.data); err != nil {
+				return err
+			}
+		}
+		return s.buffer.Flush()
+	})
+}
+
+func (s *Stream) connect(ctx context.Context) error {
+	// Close the existing connection
+	s.disconnect()
+
 	// Open new connection
-	address := fmt.Sprintf("%s:%d", s.client.GetHost(), s.client.GetPort())
-	conn, err := net.Dial("tcp", address)
+	conn, err := s.client.DialContext(ctx)
 	if err != nil {
-		return err
+		return s.connectError(err)
 	}
 
 	// Write the request header (chunked transfer encoding)
-	if _, err = conn.Write(s.header); err != nil {
+	header, err := s.requestHeader(ctx)
+	if err != nil {
 		conn.Close()
 		return err
 	}
+	if err = interruptible(ctx, conn, func() error { _, err := conn.Write(header); return err }); err != nil {
+		conn.Close()
+		return s.connectError(err)
+	}
 
 	// Finish setting up the stream
 	s.conn = conn
-	s.chunker = &chunkWriter{conn}
+	s.chunker = &chunkWriter{w: conn, written: func(n int) {
+		countStream(s.client, s.path, StreamChunks, 1)
+		countStream(s.client, s.path, StreamBytes, n)
+	}}
 	s.buffer = bufio.NewWriter(s.chunker)
-	s.encoder = json.NewEncoder(s.buffer)
 	return nil
 }
 
+// connectError wraps a failure to reach the server like a failed request so
+// that it matches ErrUnavailable. Errors from the client are returned as is.
+func (s *Stream) connectError(err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Method: "PATCH", URL: s.client.URL(s.path), Err: err}
+}
+
+// requestHeader builds the stream's request header. It is rebuilt on every
+// connection so that authenticators can supply fresh credentials.
+func (s *Stream) requestHeader(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "PATCH", s.client.URL(s.path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Transfer-Encoding", "chunked")
+	if userAgent := s.client.UserAgent(); userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	injectTrace(ctx, s.client, req.Header)
+	if auth := s.client.Authenticator(); auth != nil {
+		if err := auth.Authenticate(req); err != nil {
+			return nil, err
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "PATCH %s HTTP/1.0\r\nHost: %s\r\n", req.URL.RequestURI(), req.URL.Host)
+	req.Header.Write(&b)
+	b.WriteString("\r\n")
+	return b.Bytes(), nil
+}
+
+// spanAttributes returns the attributes shared by the stream's spans.
+func (s *Stream) spanAttributes() map[string]interface{} {
+	attrs := map[string]interface{}{"url.path": s.path, "sky.events": s.count, "sky.bytes": s.size}
+	if table := tableName(s.path); table != "" {
+		attrs["sky.table"] = table
+	}
+	return attrs
+}
+
+func (s *Stream) disconnect() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn, s.chunker, s.buffer = nil, nil, nil
+}
+
+// write encodes data into the stream and holds it for replay if the stream
+// is replayable. Data is appended to the spool instead while it holds events.
+func (s *Stream) write(ctx context.Context, data map[string]interface{}, event *UndeliveredEvent) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	countStream(s.client, s.path, StreamEvents, 1)
+
+	if s.spooling() {
+		if err := s.drainIfDue(ctx); err != nil {
+			return err
+		}
+		if s.spooling() {
+			return s.spool.append(b)
+		}
+	}
+
+	if s.replayable() {
+		s.pending = append(s.pending, &pendingEvent{data: b, event: event})
+	}
+	s.count++
+	s.size += len(b)
+	if s.buffer == nil {
+		return s.recover(ctx, errors.New("Stream is not connected"), new(int))
+	}
+	if err := interruptible(ctx, s.conn, func() error { _, err := s.buffer.Write(b); return err }); err != nil {
+		return s.recover(ctx, err, new(int))
+	}
+
+	if s.policy != nil && s.policy.CommitEvery > 0 && len(s.pending) >= s.policy.CommitEvery {
+		return s.CommitContext(ctx)
+	}
+	return nil
+}
+
+// replayable returns true if written events are held until they are
+// acknowledged so they can be replayed on a new connection. That is the case
+// with a reconnect policy, a spool or credentials that can be refreshed.
+func (s *Stream) replayable() bool {
+	_, ok := s.client.Authenticator().(Refresher)
+	return s.policy != nil || s.spool != nil || ok
+}
+
+// recover reconnects and replays pending events after a failure. Each
+// reconnect counts against attempts, which callers share across repeated
+// failures so that the policy's MaxAttempts caps the total. If the stream has
+// no reconnect policy then the original error is returned, or the pending
+// events are spooled if there is a spool.
+func (s *Stream) recover(ctx context.Context, cause error, attempts *int) error {
+	if s.policy == nil {
+		if s.spool != nil {
+			return s.fail(ctx, cause)
+		}
+		return cause
+	}
+
+	err := cause
+	for *attempts < s.policy.MaxAttempts && ctx.Err() == nil {
+		attempt := *attempts
+		*attempts++
+		logMessage(ctx, s.client, err, "sky stream reconnect", "path", s.path, "attempt", attempt+1, "pending", len(s.pending))
+		if sleep(ctx, s.policy.backoff(attempt)) != nil {
+			break
+		}
+		countStream(s.client, s.path, StreamReconnects, 1)
+		if err = s.ReconnectContext(ctx); err == nil {
+			return nil
+		}
+	}
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return s.fail(ctx, err)
+}
+
+// fail drops the connection and all pending events, which are returned to the
+// caller as undelivered. If the server is unreachable and there is a spool
+// then the events are spooled instead. Without a reconnect policy or spool
+// the error is returned as-is.
+func (s *Stream) fail(ctx context.Context, err error) error {
+	s.disconnect()
+	if s.spool != nil && spoolable(err) {
+		count := len(s.pending)
+		serr := s.spoolPending()
+		logMessage(ctx, s.client, err, "sky stream spool", "path", s.path, "events", count-len(s.pending))
+		if serr == nil {
+			return nil
+		}
+		err = errors.Join(err, serr)
+	}
+	if s.policy == nil && s.spool == nil {
+		s.pending, s.count, s.size = nil, 0, 0
+		return err
+	}
+	undelivered := make([]*UndeliveredEvent, 0, len(s.pending))
+	for _, p := range s.pending {
+		undelivered = append(undelivered, p.event)
+	}
+	logMessage(ctx, s.client, err, "sky stream failed", "path", s.path, "undelivered", len(undelivered))
+	s.pending, s.count, s.size = nil, 0, 0
+	return &StreamError{Err: err, Undelivered: undelivered}
+}
+
+//--------------------------------------
+// Errors
+//--------------------------------------
+
+// The error message.
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("sky.Stream: %d events undelivered: %v", len(e.Undelivered), e.Err)
+}
+
+// The underlying error.
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
+
+// spoolable returns true if an error means the server could not be reached,
+// rather than that it rejected the events.
+func spoolable(err error) bool {
+	var e *Error
+	return !errors.As(err, &e) || IsUnavailable(err)
+}
+
+// interruptible runs fn and interrupts any blocking I/O on conn once ctx is
+// done. The context's error is returned if it caused fn to fail.
+func interruptible(ctx context.Context, conn net.Conn, fn func() error) error {
+	if conn == nil || ctx.Done() == nil {
+		return fn()
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// Expire the deadline immediately if the context is cancelled.
+	done, exited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Unix(1, 0))
+		case <-done:
+		}
+	}()
+
+	err := fn()
+	close(done)
+	<-exited
+	conn.SetDeadline(time.Time{})
+
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
 // chunkWriter is an io.Writer that will emit any writes in HTTP chunk format
 type chunkWriter struct {
 	w io.Writer
+
+	// Called with the size of each chunk written, if set.
+	written func(n int)
 }
 
 func (cw *chunkWriter) Write(p []byte) (int, error) {
@@ -196,5 +801,8 @@ func (cw *chunkWriter) Write(p []byte) (int, error) {
 	if _, err = fmt.Fprint(cw.w, "\r\n"); err != nil {
 		return total, err
 	}
+	if cw.written != nil && total > 0 {
+		cw.written(total)
+	}
 	return total, nil
 }
@@ -0,0 +1,157 @@
+package sky
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+// Ensure that a stream reconnects and replays events after its connection drops.
+func TestStreamReconnectReplay(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		stream, err := table.Stream()
+		if err != nil {
+			t.Fatalf("Failed to create event stream: (%v)", err)
+		}
+		stream.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+		now := time.Now()
+		for i := 0; i < 10; i++ {
+			if i == 5 {
+				// Drop the connection underneath the stream.
+				stream.conn.Close()
+			}
+			if err := stream.AddEvent("xyz", NewEvent(now.Add(time.Duration(i)*time.Hour), nil)); err != nil {
+				t.Fatalf("Failed to add event #%d: (%v)", i, err)
+			}
+		}
+		if stream.Pending() != 10 {
+			t.Fatalf("Expected 10 pending events: %d", stream.Pending())
+		}
+		if err = stream.Close(); err != nil {
+			t.Fatalf("Closing stream failed: (%v)", err)
+		}
+		if stream.Pending() != 0 {
+			t.Fatalf("Expected no pending events: %d", stream.Pending())
+		}
+		events, err := table.GetEvents("xyz")
+		if err != nil || len(events) != 10 {
+			t.Fatalf("Failed to get 10 events back: %d events, (%v)", len(events), err)
+		}
+	})
+}
+
+// Ensure that a stream commits automatically to bound its replay buffer.
+func TestStreamCommitEvery(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		stream, err := table.Stream()
+		if err != nil {
+			t.Fatalf("Failed to create event stream: (%v)", err)
+		}
+		stream.SetReconnectPolicy(&ReconnectPolicy{CommitEvery: 4})
+
+		now := time.Now()
+		for i := 0; i < 10; i++ {
+			if err := stream.AddEvent("xyz", NewEvent(now.Add(time.Duration(i)*time.Hour), nil)); err != nil {
+				t.Fatalf("Failed to add event #%d: (%v)", i, err)
+			}
+		}
+		if stream.Pending() != 2 {
+			t.Fatalf("Expected 2 pending events: %d", stream.Pending())
+		}
+		if err = stream.Close(); err != nil {
+			t.Fatalf("Closing stream failed: (%v)", err)
+		}
+		events, err := table.GetEvents("xyz")
+		if err != nil || len(events) != 10 {
+			t.Fatalf("Failed to get 10 events back: %d events, (%v)", len(events), err)
+		}
+	})
+}
+
+// Ensure that undelivered events are reported when the server is gone.
+func TestStreamUndelivered(t *testing.T) {
+	server := skytest.NewServer()
+	client := NewClientEx(server.Host(), server.Port())
+	table := NewTable(testTableName, nil)
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to setup test table: %v", err)
+	}
+
+	stream, err := table.Stream()
+	if err != nil {
+		t.Fatalf("Failed to create event stream: (%v)", err)
+	}
+	stream.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 2, Backoff: time.Millisecond})
+	for i := 0; i < 3; i++ {
+		stream.AddEvent("xyz", NewEvent(time.Unix(int64(i), 0), nil))
+	}
+
+	// Stop the server and drop the connection underneath the stream.
+	server.Close()
+	stream.conn.Close()
+
+	err = stream.Close()
+	var streamErr *StreamError
+	if !errors.As(err, &streamErr) || len(streamErr.Undelivered) != 3 {
+		t.Fatalf("Expected 3 undelivered events: %v", err)
+	}
+	if e := streamErr.Undelivered[1]; e.Table != testTableName || e.ObjectId != "xyz" || !e.Event.Timestamp.Equal(time.Unix(1, 0)) {
+		t.Fatalf("Invalid undelivered event: %v", e)
+	}
+}
+
+// flakyDialer is a client whose reconnects fail on every other attempt.
+type flakyDialer struct {
+	Client
+	dials int32
+}
+
+func (c *flakyDialer) DialContext(ctx context.Context) (net.Conn, error) {
+	if atomic.AddInt32(&c.dials, 1)%2 == 0 {
+		return nil, errors.New("connection refused")
+	}
+	return c.Client.DialContext(ctx)
+}
+
+// Ensure that reconnects made while committing are capped by MaxAttempts in
+// total rather than per commit attempt.
+func TestStreamCommitAttempts(t *testing.T) {
+	// The server drops every connection so that commits always fail.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	client := &flakyDialer{Client: NewClientEx("127.0.0.1", uint(addr.Port))}
+	stream, err := NewTable(testTableName, client).Stream()
+	if err != nil {
+		t.Fatalf("Failed to create event stream: (%v)", err)
+	}
+	stream.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+	stream.AddEvent("xyz", NewEvent(time.Unix(0, 0), nil))
+
+	var streamErr *StreamError
+	if err := stream.Close(); !errors.As(err, &streamErr) {
+		t.Fatalf("Expected stream error: %v", err)
+	}
+	if dials := atomic.LoadInt32(&client.dials); dials > 4 {
+		t.Fatalf("Expected at most 3 reconnects: %d", dials-1)
+	}
+}
@@ -0,0 +1,274 @@
+package sky
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// A structField describes how a struct field maps to an event property.
+type structField struct {
+	index     int
+	name      string
+	dataType  string
+	transient bool
+	omitEmpty bool
+}
+
+// A structMapping describes how a struct type maps to an event.
+type structMapping struct {
+	timestamp int
+	fields    []*structField
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// MarshalEvent creates an event from a tagged struct.
+//
+// Each exported field maps to a property named by its `sky` tag, or by the
+// field name if there is no tag. The tag can also list the property's data
+// type and the options "transient" and "omitempty", for example
+// `sky:"gender,factor,omitempty"`. If the data type is omitted then it is
+// inferred from the field's kind. A tag of "-" skips the field.
+//
+// The event timestamp is taken from the time.Time field tagged
+// `sky:",timestamp"`, otherwise from a time.Time field named Timestamp,
+// otherwise from the first time.Time field.
+func MarshalEvent(v interface{}) (*Event, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sky.Event: Cannot marshal %T", v)
+	}
+	m, err := getStructMapping(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	event := NewEvent(rv.Field(m.timestamp).Interface().(time.Time), map[string]interface{}{})
+	for _, f := range m.fields {
+		fv := rv.Field(f.index)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		} else if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		switch fv.Kind() {
+		case reflect.String:
+			event.Data[f.name] = fv.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			event.Data[f.name] = fv.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			event.Data[f.name] = fv.Uint()
+		case reflect.Float32, reflect.Float64:
+			event.Data[f.name] = fv.Float()
+		case reflect.Bool:
+			event.Data[f.name] = fv.Bool()
+		}
+	}
+	return event, nil
+}
+
+// UnmarshalEvent copies an event's timestamp and data into a tagged struct.
+// See MarshalEvent for how fields are mapped. Untagged fields match property
+// names case-insensitively.
+func UnmarshalEvent(event *Event, v interface{}) error {
+	if event == nil {
+		return errors.New("sky.Event: Unable to unmarshal nil.")
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sky.Event: Cannot unmarshal into %T", v)
+	}
+	return unmarshalEvent(event, rv.Elem())
+}
+
+// UnmarshalEvents copies a list of events into a pointer to a slice of
+// tagged structs or struct pointers.
+func UnmarshalEvents(events []*Event, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("sky.Event: Cannot unmarshal into %T", v)
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	if derefType(elemType).Kind() != reflect.Struct {
+		return fmt.Errorf("sky.Event: Cannot unmarshal into %T", v)
+	}
+
+	output := reflect.MakeSlice(slice.Type(), 0, len(events))
+	for _, event := range events {
+		item := reflect.New(derefType(elemType)).Elem()
+		if err := unmarshalEvent(event, item); err != nil {
+			return err
+		}
+		if elemType.Kind() == reflect.Ptr {
+			item = item.Addr()
+		}
+		output = reflect.Append(output, item)
+	}
+	slice.Set(output)
+	return nil
+}
+
+// StructProperties returns the properties described by a tagged struct.
+func StructProperties(v interface{}) ([]*Property, error) {
+	t := reflect.TypeOf(v)
+	if t == nil || derefType(t).Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sky.Event: Cannot map %T", v)
+	}
+	m, err := getStructMapping(derefType(t))
+	if err != nil {
+		return nil, err
+	}
+	properties := make([]*Property, 0, len(m.fields))
+	for _, f := range m.fields {
+		properties = append(properties, NewProperty(f.name, f.transient, f.dataType))
+	}
+	return properties, nil
+}
+
+func unmarshalEvent(event *Event, rv reflect.Value) error {
+	m, err := getStructMapping(rv.Type())
+	if err != nil {
+		return err
+	}
+	rv.Field(m.timestamp).Set(reflect.ValueOf(event.Timestamp))
+
+	for _, f := range m.fields {
+		value, ok := event.Data[f.name]
+		if !ok {
+			for k, v := range event.Data {
+				if strings.EqualFold(k, f.name) {
+					value, ok = v, true
+					break
+				}
+			}
+		}
+		if !ok || value == nil {
+			continue
+		}
+		if err := assignValue(rv.Field(f.index), value); err != nil {
+			return fmt.Errorf("sky.Event: Cannot unmarshal %s into %s.%s: %v", f.name, rv.Type().Name(), rv.Type().Field(f.index).Name, err)
+		}
+	}
+	return nil
+}
+
+// getStructMapping parses the sky tags of a struct type.
+func getStructMapping(t reflect.Type) (*structMapping, error) {
+	m := &structMapping{timestamp: -1}
+	named, first := -1, -1
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name, ok := fieldName(sf)
+		if !ok {
+			continue
+		}
+		options := strings.Split(sf.Tag.Get("sky"), ",")[1:]
+
+		// Find the timestamp field.
+		if sf.Type == timeType {
+			if hasOption(options, "timestamp") {
+				m.timestamp = i
+			} else if sf.Name == "Timestamp" && named == -1 {
+				named = i
+			} else if first == -1 {
+				first = i
+			}
+			continue
+		}
+
+		f := &structField{index: i, name: name}
+		for _, option := range options {
+			switch option {
+			case String, Integer, Float, Boolean, Factor:
+				f.dataType = option
+			case "transient":
+				f.transient = true
+			case "omitempty":
+				f.omitEmpty = true
+			case "":
+			default:
+				return nil, fmt.Errorf("sky.Event: Invalid tag option on %s.%s: %s", t.Name(), sf.Name, option)
+			}
+		}
+
+		// Infer or validate the data type against the field's kind.
+		kind := derefType(sf.Type).Kind()
+		if f.dataType == "" {
+			f.dataType = kindDataType(kind)
+		}
+		if f.dataType == "" || !kindMatchesDataType(kind, f.dataType) {
+			return nil, fmt.Errorf("sky.Event: Cannot map %s.%s (%s) to a property", t.Name(), sf.Name, sf.Type)
+		}
+		m.fields = append(m.fields, f)
+	}
+
+	if m.timestamp == -1 {
+		m.timestamp = named
+	}
+	if m.timestamp == -1 {
+		m.timestamp = first
+	}
+	if m.timestamp == -1 {
+		return nil, fmt.Errorf("sky.Event: No timestamp field on %s", t.Name())
+	}
+	return m, nil
+}
+
+// kindDataType returns the default data type for a kind.
+func kindDataType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return String
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Integer
+	case reflect.Float32, reflect.Float64:
+		return Float
+	case reflect.Bool:
+		return Boolean
+	}
+	return ""
+}
+
+// kindMatchesDataType checks if a kind can hold values of a data type.
+func kindMatchesDataType(kind reflect.Kind, dataType string) bool {
+	switch dataType {
+	case String, Factor:
+		return kind == reflect.String
+	case Float:
+		return kindDataType(kind) == Float || kindDataType(kind) == Integer
+	}
+	return kindDataType(kind) == dataType
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
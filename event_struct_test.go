@@ -0,0 +1,80 @@
+package sky
+
+import (
+	"testing"
+	"time"
+)
+
+type purchase struct {
+	When     time.Time `sky:",timestamp"`
+	Created  time.Time `sky:"-"`
+	Gender   string    `sky:"gender,factor"`
+	Price    float64   `sky:"purchase_price"`
+	Quantity int       `sky:"quantity,omitempty"`
+	Gift     *bool     `sky:"gift"`
+	Note     string    `sky:"note,transient"`
+}
+
+// Ensure that struct tags map to properties.
+func TestStructProperties(t *testing.T) {
+	properties, err := StructProperties(&purchase{})
+	if err != nil || len(properties) != 5 {
+		t.Fatalf("Unable to map properties: %v (%v)", properties, err)
+	}
+	if p := properties[0]; p.Name != "gender" || p.DataType != Factor || p.Transient {
+		t.Fatalf("Invalid property(0): %v", p)
+	}
+	if p := properties[1]; p.Name != "purchase_price" || p.DataType != Float {
+		t.Fatalf("Invalid property(1): %v", p)
+	}
+	if p := properties[3]; p.Name != "gift" || p.DataType != Boolean {
+		t.Fatalf("Invalid property(3): %v", p)
+	}
+	if p := properties[4]; p.Name != "note" || p.DataType != String || !p.Transient {
+		t.Fatalf("Invalid property(4): %v", p)
+	}
+
+	// Mismatched data types are rejected.
+	if _, err := StructProperties(struct {
+		Timestamp time.Time
+		Count     string `sky:"count,integer"`
+	}{}); err == nil {
+		t.Fatalf("Expected data type error")
+	}
+}
+
+// Ensure that events can be written from and read into structs.
+func TestMarshalEvent(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		properties, _ := StructProperties(purchase{})
+		for _, p := range properties {
+			table.CreateProperty(p)
+		}
+
+		timestamp, _ := ParseTimestamp("1970-01-01T00:00:01.5Z")
+		gift := true
+		event, err := MarshalEvent(&purchase{When: timestamp, Gender: "f", Price: 9.5, Gift: &gift})
+		if err != nil {
+			t.Fatalf("Unable to marshal event: %v", err)
+		}
+		if _, ok := event.Data["quantity"]; ok || !event.Timestamp.Equal(timestamp) {
+			t.Fatalf("Invalid marshaled event: %v", event)
+		}
+		if err := table.AddEvent("o0", event, Replace); err != nil {
+			t.Fatalf("Unable to add event: %v", err)
+		}
+
+		events, err := table.GetEvents("o0")
+		if err != nil {
+			t.Fatalf("Unable to get events: %v", err)
+		}
+		var purchases []*purchase
+		if err := UnmarshalEvents(events, &purchases); err != nil || len(purchases) != 1 {
+			t.Fatalf("Unable to unmarshal events: %v (%v)", purchases, err)
+		}
+		p := purchases[0]
+		if !p.When.Equal(timestamp) || p.Gender != "f" || p.Price != 9.5 || p.Quantity != 0 || p.Gift == nil || !*p.Gift {
+			t.Fatalf("Invalid unmarshaled event: %v", p)
+		}
+	})
+}
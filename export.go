@@ -0,0 +1,252 @@
+package sky
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// An EventWriter writes exported events in a file format. Each event is a
+// row with the object id, the timestamp and one typed column per property.
+type EventWriter interface {
+	// Writes the header for the table's properties. It is called once
+	// before any events are written.
+	WriteHeader(properties []*Property) error
+
+	// Writes a single event.
+	WriteEvent(objectId string, event *Event) error
+
+	// Flushes buffered output. The underlying writer is not closed.
+	Close() error
+}
+
+type ndjsonWriter struct {
+	w          *bufio.Writer
+	encoder    *json.Encoder
+	properties []*Property
+}
+
+type csvWriter struct {
+	w          *csv.Writer
+	properties []*Property
+	record     []string
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// NewNDJSONWriter returns an event writer that writes one JSON object per
+// line with "id" and "timestamp" keys and a key for each property that is
+// set on the event.
+func NewNDJSONWriter(w io.Writer) EventWriter {
+	bw := bufio.NewWriter(w)
+	return &ndjsonWriter{w: bw, encoder: json.NewEncoder(bw)}
+}
+
+// NewCSVWriter returns an event writer that writes CSV with an "id" column,
+// a "timestamp" column and a column for each property. Properties that are
+// not set on an event are left blank.
+func NewCSVWriter(w io.Writer) EventWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+//--------------------------------------
+// NDJSON
+//--------------------------------------
+
+func (w *ndjsonWriter) WriteHeader(properties []*Property) error {
+	if err := checkColumns(properties); err != nil {
+		return err
+	}
+	w.properties = properties
+	return nil
+}
+
+func (w *ndjsonWriter) WriteEvent(objectId string, event *Event) error {
+	row := map[string]interface{}{"id": objectId, "timestamp": FormatTimestamp(event.Timestamp)}
+	for _, p := range w.properties {
+		if v, ok := event.Data[p.Name]; ok && v != nil {
+			row[p.Name] = ExportValue(v, p.DataType)
+		}
+	}
+	return w.encoder.Encode(row)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.w.Flush()
+}
+
+//--------------------------------------
+// CSV
+//--------------------------------------
+
+func (w *csvWriter) WriteHeader(properties []*Property) error {
+	if err := checkColumns(properties); err != nil {
+		return err
+	}
+	w.properties = properties
+	w.record = make([]string, len(properties)+2)
+	header := []string{"id", "timestamp"}
+	for _, p := range properties {
+		header = append(header, p.Name)
+	}
+	return w.w.Write(header)
+}
+
+func (w *csvWriter) WriteEvent(objectId string, event *Event) error {
+	w.record[0], w.record[1] = objectId, FormatTimestamp(event.Timestamp)
+	for i, p := range w.properties {
+		w.record[i+2] = ""
+		if v, ok := event.Data[p.Name]; ok && v != nil {
+			w.record[i+2] = formatValue(ExportValue(v, p.DataType))
+		}
+	}
+	return w.w.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// Export writes the events of the given objects to an event writer and
+// returns the number of events written. If objectIds is nil then every
+// object in the table is exported, which requires the server to support
+// listing objects. The writer is closed after the last event.
+func Export(table Table, w EventWriter, objectIds []string) (int, error) {
+	return ExportContext(context.Background(), table, w, objectIds)
+}
+
+// ExportContext writes the events of the given objects to an event writer
+// using the given context. The writer is closed even if the export fails so
+// that the events written so far are flushed.
+func ExportContext(ctx context.Context, table Table, w EventWriter, objectIds []string) (count int, err error) {
+	defer func() {
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	if table == nil || table.Client() == nil {
+		return 0, errors.New("Table is not attached to a client")
+	}
+
+	properties, err := table.GetPropertiesContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	properties = append([]*Property{}, properties...)
+	sort.Slice(properties, func(i, j int) bool { return properties[i].Name < properties[j].Name })
+
+	if objectIds == nil {
+		if objectIds, err = listObjects(ctx, table); err != nil {
+			return 0, fmt.Errorf("sky.Export: Unable to list objects, pass object ids instead: %w", err)
+		}
+	}
+
+	if err := w.WriteHeader(properties); err != nil {
+		return 0, err
+	}
+	for _, objectId := range objectIds {
+		events, err := table.GetEventsContext(ctx, objectId)
+		if err != nil {
+			return count, err
+		}
+		for _, event := range events {
+			if err := w.WriteEvent(objectId, event); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// checkColumns returns an error if a property would overwrite the "id" or
+// "timestamp" column.
+func checkColumns(properties []*Property) error {
+	for _, p := range properties {
+		if p.Name == "id" || p.Name == "timestamp" {
+			return fmt.Errorf("sky.Export: Property conflicts with a built-in column: %s", p.Name)
+		}
+	}
+	return nil
+}
+
+// listObjects retrieves the ids of every object in a table.
+func listObjects(ctx context.Context, table Table) ([]string, error) {
+	it, err := table.ObjectsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	objectIds := []string{}
+	for it.Next() {
+		objectIds = append(objectIds, it.Id())
+	}
+	return objectIds, it.Err()
+}
+
+// ExportValue converts an event value decoded from JSON into the Go type for
+// a property data type: int64 for integers, float64 for floats, bool for
+// booleans and string for strings and factors.
+func ExportValue(value interface{}, dataType string) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if dataType == Integer {
+			return int64(v)
+		}
+	case json.Number:
+		if dataType == Integer {
+			if i, err := v.Int64(); err == nil {
+				return i
+			}
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// formatValue formats an exported value as a CSV cell.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,122 @@
+package sky
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+// setupExport creates typed properties and events for exporting.
+func setupExport(t *testing.T, table Table) {
+	table.CreateProperty(NewProperty("name", false, String))
+	table.CreateProperty(NewProperty("age", false, Integer))
+	table.CreateProperty(NewProperty("active", true, Boolean))
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	table.AddEvent("u1", NewEvent(t0, map[string]interface{}{"name": "bob, jr", "age": 41}), Replace)
+	table.AddEvent("u1", NewEvent(t0.Add(time.Hour), map[string]interface{}{"active": true}), Replace)
+	table.AddEvent("u2", NewEvent(t0, map[string]interface{}{"age": 7}), Replace)
+}
+
+// Ensure that objects can be exported to NDJSON.
+func TestExportNDJSON(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		setupExport(t, table)
+		var buf bytes.Buffer
+		count, err := Export(table, NewNDJSONWriter(&buf), []string{"u2", "u1"})
+		if err != nil || count != 3 {
+			t.Fatalf("Unable to export: %d (%v)", count, err)
+		}
+		expected := `{"age":7,"id":"u2","timestamp":"2024-01-01T00:00:00Z"}` + "\n" +
+			`{"age":41,"id":"u1","name":"bob, jr","timestamp":"2024-01-01T00:00:00Z"}` + "\n" +
+			`{"active":true,"id":"u1","timestamp":"2024-01-01T01:00:00Z"}` + "\n"
+		if buf.String() != expected {
+			t.Fatalf("Unexpected NDJSON: %s", buf.String())
+		}
+	})
+}
+
+// Ensure that a whole table can be exported to CSV.
+func TestExportCSV(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		setupExport(t, table)
+		var buf bytes.Buffer
+		count, err := Export(table, NewCSVWriter(&buf), nil)
+		if err != nil || count != 3 {
+			t.Fatalf("Unable to export: %d (%v)", count, err)
+		}
+		expected := "id,timestamp,active,age,name\n" +
+			"u1,2024-01-01T00:00:00Z,,41,\"bob, jr\"\n" +
+			"u1,2024-01-01T01:00:00Z,true,,\n" +
+			"u2,2024-01-01T00:00:00Z,,7,\n"
+		if buf.String() != expected {
+			t.Fatalf("Unexpected CSV: %s", buf.String())
+		}
+	})
+}
+
+// Ensure that the events written before a failure are flushed.
+func TestExportError(t *testing.T) {
+	server := skytest.NewUnstartedServer()
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tables/"+testTableName+"/objects/u2/events" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+
+	client := NewClientEx(server.Host(), server.Port())
+	table := NewTable(testTableName, nil)
+	client.CreateTable(table)
+	setupExport(t, table)
+	var buf bytes.Buffer
+	count, err := Export(table, NewCSVWriter(&buf), []string{"u1", "u2"})
+	if err == nil || count != 2 {
+		t.Fatalf("Expected export error: %d (%v)", count, err)
+	}
+	expected := "id,timestamp,active,age,name\n" +
+		"u1,2024-01-01T00:00:00Z,,41,\"bob, jr\"\n" +
+		"u1,2024-01-01T01:00:00Z,true,,\n"
+	if buf.String() != expected {
+		t.Fatalf("Unexpected CSV: %s", buf.String())
+	}
+}
+
+// Ensure that exporting a whole table fails clearly without an objects
+// endpoint.
+func TestExportWithoutObjects(t *testing.T) {
+	server := skytest.NewUnstartedServer()
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tables/"+testTableName+"/objects" {
+			http.NotFound(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+
+	client := NewClientEx(server.Host(), server.Port())
+	table := NewTable(testTableName, nil)
+	client.CreateTable(table)
+	if _, err := Export(table, NewNDJSONWriter(&bytes.Buffer{}), nil); !IsUnsupported(err) {
+		t.Fatalf("Expected unsupported error: %v", err)
+	}
+}
+
+// Ensure that properties named after the key columns are rejected.
+func TestExportColumnConflict(t *testing.T) {
+	properties := []*Property{NewProperty("name", false, String), NewProperty("timestamp", false, String)}
+	for _, w := range []EventWriter{NewNDJSONWriter(&bytes.Buffer{}), NewCSVWriter(&bytes.Buffer{})} {
+		if err := w.WriteHeader(properties); err == nil {
+			t.Fatalf("Expected column conflict for %T", w)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package sky
+
+import (
+	"context"
+	"log/slog"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// The value logged in place of request bodies unless WithLogBodies is set.
+const redactedBody = "[redacted]"
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// A Logger receives diagnostic messages about requests and streams. It is
+// satisfied by *slog.Logger.
+//
+// Routine messages are logged at the client's log level, which defaults to
+// slog.LevelDebug. Failures are logged at slog.LevelWarn or the client's log
+// level if that is higher.
+type Logger interface {
+	Enabled(ctx context.Context, level slog.Level) bool
+	Log(ctx context.Context, level slog.Level, msg string, args ...any)
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// logMessage logs a message to the client's logger, if it has one. A non-nil
+// error raises the level and is added to the message.
+func logMessage(ctx context.Context, c Client, err error, msg string, args ...any) {
+	logger := c.Logger()
+	if logger == nil {
+		return
+	}
+	level := c.LogLevel()
+	if err != nil {
+		if level < slog.LevelWarn {
+			level = slog.LevelWarn
+		}
+		args = append(args, "error", err)
+	}
+	if logger.Enabled(ctx, level) {
+		logger.Log(ctx, level, msg, args...)
+	}
+}
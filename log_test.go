@@ -0,0 +1,85 @@
+package sky
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+// Ensure that requests and streams are logged at the configured level with
+// bodies redacted.
+func TestLogger(t *testing.T) {
+	server := skytest.NewServer()
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	// Routine messages default to the debug level.
+	client := NewClientEx(server.Host(), server.Port(), WithLogger(logger))
+	if !client.Ping() || buf.Len() != 0 {
+		t.Fatalf("Unexpected debug output: %s", buf.String())
+	}
+
+	client = NewClientEx(server.Host(), server.Port(), WithLogger(logger), WithLogLevel(slog.LevelInfo))
+	table := NewTable(testTableName, nil)
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to create table: %v", err)
+	}
+	if _, err := client.GetTable("missing"); err == nil {
+		t.Fatalf("Expected missing table")
+	}
+	stream, err := table.Stream()
+	if err != nil {
+		t.Fatalf("Failed to create event stream: (%v)", err)
+	}
+	stream.AddEvent("xyz", NewEvent(time.Now(), nil))
+	stream.Flush()
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Closing stream failed: (%v)", err)
+	}
+
+	records := decodeLog(t, &buf)
+	var messages []string
+	for _, r := range records {
+		messages = append(messages, r["msg"].(string))
+	}
+	if strings.Join(messages, ",") != "sky request,sky request,sky stream connect,sky stream flush,sky stream commit,sky stream close" {
+		t.Fatalf("Unexpected messages: %v", messages)
+	}
+	if r := records[0]; r["level"] != "INFO" || r["method"] != "POST" || r["path"] != "/tables" || r["status"] != float64(200) || r["body"] != redactedBody {
+		t.Fatalf("Unexpected request record: %v", r)
+	}
+	if r := records[1]; r["level"] != "WARN" || r["status"] != float64(404) || r["error"] == nil {
+		t.Fatalf("Unexpected failed request record: %v", r)
+	}
+	if r := records[4]; r["events"] != float64(1) || r["status"] != float64(200) {
+		t.Fatalf("Unexpected commit record: %v", r)
+	}
+
+	// Bodies are only logged when requested.
+	client = NewClientEx(server.Host(), server.Port(), WithLogger(logger), WithLogLevel(slog.LevelInfo), WithLogBodies())
+	client.CreateTable(NewTable("secret", nil))
+	if records := decodeLog(t, &buf); len(records) != 1 || !strings.Contains(records[0]["body"].(string), "secret") {
+		t.Fatalf("Expected logged body: %v", records)
+	}
+}
+
+// decodeLog reads and resets the JSON log records in a buffer.
+func decodeLog(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var r map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("Invalid log record: %q (%v)", line, err)
+		}
+		records = append(records, r)
+	}
+	buf.Reset()
+	return records
+}
@@ -0,0 +1,124 @@
+package sky
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// Stream counters reported to a metrics collector.
+const (
+	StreamEvents        = "events"
+	StreamBytes         = "bytes"
+	StreamChunks        = "chunks"
+	StreamReconnects    = "reconnects"
+	StreamCloseFailures = "close_failures"
+)
+
+// Error classes reported to a metrics collector.
+const (
+	ErrorClassNone         = "none"
+	ErrorClassBadRequest   = "bad_request"
+	ErrorClassUnauthorized = "unauthorized"
+	ErrorClassNotFound     = "not_found"
+	ErrorClassConflict     = "conflict"
+	ErrorClassClient       = "client"
+	ErrorClassServer       = "server"
+	ErrorClassUnavailable  = "unavailable"
+	ErrorClassTimeout      = "timeout"
+	ErrorClassCanceled     = "canceled"
+	ErrorClassOther        = "other"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// A Collector records metrics about requests and streams. Endpoints are
+// request paths with names and identifiers replaced by placeholders, such as
+// "/tables/:table/properties/:property", so that they can be used as labels.
+//
+// Collectors are called synchronously and must be safe for concurrent use.
+// Adapters for Prometheus and OpenTelemetry are in the skyprometheus and
+// skyotel packages.
+type Collector interface {
+	// Records a request attempt, its latency and the class of error it
+	// failed with, or ErrorClassNone.
+	ObserveRequest(method string, endpoint string, latency time.Duration, class string)
+
+	// Adds to one of the stream counters, such as StreamEvents.
+	AddStreamCount(endpoint string, counter string, n int)
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// ErrorClass classifies an error from a request or stream into one of the
+// ErrorClass constants.
+func ErrorClass(err error) string {
+	var e *Error
+	switch {
+	case err == nil:
+		return ErrorClassNone
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, ErrBadRequest):
+		return ErrorClassBadRequest
+	case errors.Is(err, ErrUnauthorized):
+		return ErrorClassUnauthorized
+	case errors.Is(err, ErrNotFound):
+		return ErrorClassNotFound
+	case errors.Is(err, ErrConflict):
+		return ErrorClassConflict
+	case errors.Is(err, ErrUnavailable):
+		return ErrorClassUnavailable
+	case errors.As(err, &e) && e.StatusCode >= http.StatusInternalServerError:
+		return ErrorClassServer
+	case errors.As(err, &e) && e.StatusCode >= http.StatusBadRequest:
+		return ErrorClassClient
+	}
+	return ErrorClassOther
+}
+
+// Endpoint returns the endpoint label for a request path.
+func Endpoint(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 1 && segments[0] == "tables" {
+		segments[1] = ":table"
+		if len(segments) > 3 {
+			switch segments[2] {
+			case "properties":
+				segments[3] = ":property"
+			case "objects":
+				segments[3] = ":id"
+				if len(segments) > 5 {
+					segments[5] = ":timestamp"
+				}
+			}
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// countStream adds to a stream counter if the client has a collector.
+func countStream(c Client, path string, counter string, n int) {
+	if collector := c.Metrics(); collector != nil && n > 0 {
+		collector.AddStreamCount(Endpoint(path), counter, n)
+	}
+}
@@ -0,0 +1,92 @@
+package sky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+type testCollector struct {
+	sync.Mutex
+	requests map[string]int
+	streams  map[string]int
+}
+
+func (c *testCollector) ObserveRequest(method string, endpoint string, latency time.Duration, class string) {
+	c.Lock()
+	defer c.Unlock()
+	c.requests[fmt.Sprintf("%s %s %s", method, endpoint, class)]++
+}
+
+func (c *testCollector) AddStreamCount(endpoint string, counter string, n int) {
+	c.Lock()
+	defer c.Unlock()
+	c.streams[endpoint+" "+counter] += n
+}
+
+// Ensure that request and stream metrics are recorded.
+func TestMetrics(t *testing.T) {
+	server := skytest.NewServer()
+	defer server.Close()
+
+	collector := &testCollector{requests: map[string]int{}, streams: map[string]int{}}
+	client := NewClientEx(server.Host(), server.Port(), WithMetrics(collector))
+	table := NewTable(testTableName, nil)
+	client.CreateTable(table)
+	client.GetTable("missing")
+	table.GetProperty("missing")
+
+	stream, err := table.Stream()
+	if err != nil {
+		t.Fatalf("Failed to create event stream: (%v)", err)
+	}
+	stream.SetReconnectPolicy(&ReconnectPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+	stream.AddEvent("xyz", NewEvent(time.Now(), nil))
+	stream.Flush()
+	stream.conn.Close()
+	stream.AddEvent("xyz", NewEvent(time.Now().Add(time.Hour), nil))
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Closing stream failed: (%v)", err)
+	}
+
+	for _, key := range []string{"POST /tables none", "GET /tables/:table not_found", "GET /tables/:table/properties/:property not_found"} {
+		if collector.requests[key] != 1 {
+			t.Fatalf("Expected request %q: %v", key, collector.requests)
+		}
+	}
+	streams := collector.streams
+	if streams["/tables/:table/events events"] != 2 || streams["/tables/:table/events close_failures"] != 1 || streams["/tables/:table/events reconnects"] != 1 {
+		t.Fatalf("Unexpected stream counts: %v", streams)
+	}
+	if streams["/tables/:table/events chunks"] < 2 || streams["/tables/:table/events bytes"] == 0 {
+		t.Fatalf("Unexpected stream writes: %v", streams)
+	}
+}
+
+// Ensure that errors are classified.
+func TestErrorClass(t *testing.T) {
+	tests := map[error]string{
+		nil:                                               ErrorClassNone,
+		context.DeadlineExceeded:                          ErrorClassTimeout,
+		&Error{Method: "GET", StatusCode: 401}:            ErrorClassUnauthorized,
+		&Error{Method: "GET", StatusCode: 422}:            ErrorClassClient,
+		&Error{Method: "GET", StatusCode: 500}:            ErrorClassServer,
+		&Error{Method: "GET", StatusCode: 503}:            ErrorClassUnavailable,
+		&Error{Method: "GET", Err: errors.New("refused")}: ErrorClassUnavailable,
+		&Error{Method: "GET", Err: context.Canceled}:      ErrorClassCanceled,
+		errors.New("unexpected end of JSON input"):        ErrorClassOther,
+	}
+	for err, class := range tests {
+		if ErrorClass(err) != class {
+			t.Fatalf("Unexpected class for %v: %s", err, ErrorClass(err))
+		}
+	}
+	if Endpoint("/tables/foo/objects/bar/events/2013-01-01T00:00:00Z") != "/tables/:table/objects/:id/events/:timestamp" {
+		t.Fatalf("Unexpected endpoint: %s", Endpoint("/tables/foo/objects/bar/events/x"))
+	}
+}
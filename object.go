@@ -0,0 +1,278 @@
+package sky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// The key of the object endpoints in a client's endpoint cache.
+const objectsEndpoint = "objects"
+
+// errObjectsUnsupported is returned when the server has no object
+// endpoints. Object ids can't be listed without them since queries don't
+// expose object ids.
+var errObjectsUnsupported = fmt.Errorf("sky.Table: Object endpoints: %w", ErrUnsupported)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// ObjectSummary describes an object's events.
+type ObjectSummary struct {
+	Id         string    `json:"id"`
+	EventCount int       `json:"count"`
+	FirstEvent time.Time `json:"first"`
+	LastEvent  time.Time `json:"last"`
+}
+
+// An ObjectIterator reads a table's object ids one at a time as they are
+// decoded from the server's response. It must be closed when it is no
+// longer needed.
+type ObjectIterator struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+	id      string
+	err     error
+	done    bool
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// Retrieves an iterator over the ids of the table's objects.
+func (t *table) Objects() (*ObjectIterator, error) {
+	return t.ObjectsContext(context.Background())
+}
+
+// Retrieves an iterator over the ids of the table's objects using the given
+// context. The context applies until the iterator is closed. An error
+// matching ErrUnsupported is returned if the server has no object endpoints.
+func (t *table) ObjectsContext(ctx context.Context) (*ObjectIterator, error) {
+	if t.client == nil {
+		return nil, errors.New("Table is not attached to a client")
+	}
+	body, err := t.openObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	it := &ObjectIterator{body: body}
+
+	// Read the opening bracket of the id array.
+	it.decoder = json.NewDecoder(it.body)
+	if tok, err := it.decoder.Token(); err != nil || tok != json.Delim('[') {
+		it.body.Close()
+		if err == nil {
+			err = fmt.Errorf("sky.ObjectIterator: Expected object id array: %v", tok)
+		}
+		return nil, err
+	}
+	return it, nil
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+//--------------------------------------
+// Table
+//--------------------------------------
+
+// Checks whether an object has any events.
+func (t *table) ObjectExists(objectId string) (bool, error) {
+	return t.ObjectExistsContext(context.Background(), objectId)
+}
+
+// Checks whether an object has any events using the given context. If the
+// server has no object endpoints then at most one of the object's events is
+// read instead.
+func (t *table) ObjectExistsContext(ctx context.Context, objectId string) (bool, error) {
+	if supported, known := t.objectEndpoints(); known && !supported {
+		it, err := t.EventsContext(ctx, objectId, &EventOptions{Limit: 1})
+		if err != nil {
+			return false, err
+		}
+		defer it.Close()
+		exists := it.Next()
+		return exists, it.Err()
+	}
+	if _, err := t.GetObjectContext(ctx, objectId); IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Retrieves a summary of an object's events.
+func (t *table) GetObject(objectId string) (*ObjectSummary, error) {
+	return t.GetObjectContext(context.Background(), objectId)
+}
+
+// Retrieves a summary of an object's events using the given context. If the
+// server doesn't have the object endpoints then the summary is computed from
+// the object's events, one at a time. A not found error is returned if the
+// object has no events.
+func (t *table) GetObjectContext(ctx context.Context, objectId string) (*ObjectSummary, error) {
+	if t.client == nil {
+		return nil, errors.New("Table is not attached to a client")
+	}
+	if objectId == "" {
+		return nil, errors.New("Object identifier required")
+	}
+
+	supported, known := t.objectEndpoints()
+	err := fmt.Errorf("sky.Table: Object not found: %s: %w", objectId, ErrNotFound)
+	if supported || !known {
+		summary := &ObjectSummary{}
+		if err = t.client.SendContext(ctx, "GET", fmt.Sprintf("/tables/%s/objects/%s", t.name, objectId), nil, summary); err == nil {
+			t.setObjectEndpoints(true)
+			return summary, nil
+		} else if !IsNotFound(err) || known {
+			return nil, err
+		}
+	}
+
+	// Fall back to reading the object's events. Finding any means that the
+	// server has no object endpoints.
+	summary, serr := t.summarizeEvents(ctx, objectId)
+	if serr != nil {
+		return nil, serr
+	} else if summary.EventCount == 0 {
+		return nil, err
+	}
+	t.setObjectEndpoints(false)
+	return summary, nil
+}
+
+// summarizeEvents computes an object's summary by streaming its events.
+func (t *table) summarizeEvents(ctx context.Context, objectId string) (*ObjectSummary, error) {
+	it, err := t.EventsContext(ctx, objectId, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	summary := &ObjectSummary{Id: objectId}
+	for it.Next() {
+		if summary.EventCount == 0 {
+			summary.FirstEvent = it.Event().Timestamp
+		}
+		summary.LastEvent = it.Event().Timestamp
+		summary.EventCount++
+	}
+	return summary, it.Err()
+}
+
+// openObjects requests the list of the table's object ids. A missing
+// endpoint is told apart from a missing table by retrieving the table, and
+// the result is remembered by the client.
+func (t *table) openObjects(ctx context.Context) (io.ReadCloser, error) {
+	if supported, known := t.objectEndpoints(); known && !supported {
+		return nil, errObjectsUnsupported
+	}
+
+	var body io.ReadCloser
+	err := t.client.SendContext(ctx, "GET", fmt.Sprintf("/tables/%s/objects", t.name), nil, &body)
+	if IsNotFound(err) {
+		if _, terr := t.client.GetTableContext(ctx, t.name); IsNotFound(terr) {
+			return nil, err
+		} else if terr != nil {
+			return nil, terr
+		}
+		t.setObjectEndpoints(false)
+		return nil, errObjectsUnsupported
+	} else if err != nil {
+		return nil, err
+	} else if body == nil {
+		return nil, errors.New("sky.ObjectIterator: Client does not support streamed responses")
+	}
+	t.setObjectEndpoints(true)
+	return body, nil
+}
+
+// objectEndpoints returns whether the server has the object endpoints and
+// whether that is known yet. Only the package's own client remembers it.
+func (t *table) objectEndpoints() (supported bool, known bool) {
+	if c, ok := t.client.(*client); ok {
+		if v, ok := c.endpoints.Load(objectsEndpoint); ok {
+			return v.(bool), true
+		}
+	}
+	return false, false
+}
+
+// setObjectEndpoints records whether the server has the object endpoints.
+func (t *table) setObjectEndpoints(supported bool) {
+	if c, ok := t.client.(*client); ok {
+		c.endpoints.Store(objectsEndpoint, supported)
+	}
+}
+
+//--------------------------------------
+// Iterator
+//--------------------------------------
+
+// Advances to the next object id. It returns false when there are no more
+// objects or an error occurred.
+func (it *ObjectIterator) Next() bool {
+	if it.done {
+		return false
+	}
+	if it.decoder.More() {
+		tok, err := it.decoder.Token()
+		if err != nil {
+			return it.finish(err)
+		}
+		id, ok := tok.(string)
+		if !ok {
+			return it.finish(fmt.Errorf("sky.ObjectIterator: Invalid object id: %v", tok))
+		}
+		it.id = id
+		return true
+	}
+
+	// Read the closing bracket.
+	if _, err := it.decoder.Token(); err != nil {
+		return it.finish(err)
+	}
+	return it.finish(nil)
+}
+
+// Retrieves the current object id.
+func (it *ObjectIterator) Id() string {
+	return it.id
+}
+
+// Retrieves the error that stopped the iteration, if any.
+func (it *ObjectIterator) Err() error {
+	return it.err
+}
+
+// Closes the response. Any remaining ids are discarded.
+func (it *ObjectIterator) Close() error {
+	it.done = true
+	return it.body.Close()
+}
+
+// finish ends the iteration with an optional error and releases the response.
+func (it *ObjectIterator) finish(err error) bool {
+	it.done, it.err, it.id = true, err, ""
+	it.body.Close()
+	return false
+}
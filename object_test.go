@@ -0,0 +1,139 @@
+package sky
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+// Ensure that objects can be listed, checked and summarized.
+func TestObjects(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		now := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i, id := range []string{"carol", "alice", "bob", "alice"} {
+			table.AddEvent(id, NewEvent(now.Add(time.Duration(i)*time.Hour), nil), Replace)
+		}
+
+		it, err := table.Objects()
+		if err != nil {
+			t.Fatalf("Unable to list objects: %v", err)
+		}
+		defer it.Close()
+		var ids []string
+		for it.Next() {
+			ids = append(ids, it.Id())
+		}
+		if it.Err() != nil || len(ids) != 3 || ids[0] != "alice" || ids[2] != "carol" {
+			t.Fatalf("Unexpected objects: %v (%v)", ids, it.Err())
+		}
+
+		testObjectSummary(t, table, now)
+	})
+}
+
+// Ensure that a missing object costs a single request once the server is
+// known to have the object endpoints.
+func TestObjectNotFound(t *testing.T) {
+	var requests int32
+	server := skytest.NewUnstartedServer()
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+
+	client := NewClientEx(server.Host(), server.Port())
+	table := NewTable(testTableName, nil)
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to setup test table: %v", err)
+	}
+	table.AddEvent("alice", NewEvent(time.Now(), nil), Replace)
+	if _, err := table.GetObject("alice"); err != nil {
+		t.Fatalf("Unable to get object: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		atomic.StoreInt32(&requests, 0)
+		if _, err := table.GetObject("dave"); !IsNotFound(err) {
+			t.Fatalf("Expected not found: %v", err)
+		}
+		if n := atomic.LoadInt32(&requests); n != 1 {
+			t.Fatalf("Expected a single request: %d", n)
+		}
+	}
+}
+
+// Ensure that summaries are computed from events when the server has no
+// object endpoints, and that listing objects is unsupported.
+func TestObjectsFallback(t *testing.T) {
+	var requests int32
+	objectPath := regexp.MustCompile(`/objects(/[^/]+)?$`)
+	server := skytest.NewUnstartedServer()
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if objectPath.MatchString(r.URL.Path) {
+			atomic.AddInt32(&requests, 1)
+			http.NotFound(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+
+	client := NewClientEx(server.Host(), server.Port())
+	table := NewTable(testTableName, nil)
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to setup test table: %v", err)
+	}
+	now := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"carol", "alice", "bob", "alice"} {
+		table.AddEvent(id, NewEvent(now.Add(time.Duration(i)*time.Hour), nil), Replace)
+	}
+
+	// The first summary found through events disables the endpoints.
+	if _, err := table.GetObject("carol"); err != nil || atomic.LoadInt32(&requests) != 1 {
+		t.Fatalf("Unable to get object: %d (%v)", atomic.LoadInt32(&requests), err)
+	}
+	testObjectSummary(t, table, now)
+	if _, err := table.Objects(); !errors.Is(err, ErrUnsupported) || atomic.LoadInt32(&requests) != 1 {
+		t.Fatalf("Expected cached unsupported error: %d (%v)", atomic.LoadInt32(&requests), err)
+	}
+
+	// Listing is detected as unsupported without mistaking a missing table
+	// for a missing endpoint.
+	client = NewClientEx(server.Host(), server.Port())
+	if _, err := NewTable("missing", client).Objects(); !IsNotFound(err) || IsUnsupported(err) {
+		t.Fatalf("Expected table not found: %v", err)
+	}
+	if _, err := NewTable(testTableName, client).Objects(); !IsUnsupported(err) {
+		t.Fatalf("Expected unsupported error: %v", err)
+	}
+}
+
+// testObjectSummary checks the existence and summary of the objects added by
+// the object tests.
+func testObjectSummary(t *testing.T, table Table, now time.Time) {
+	if exists, err := table.ObjectExists("bob"); err != nil || !exists {
+		t.Fatalf("Expected bob to exist: %v", err)
+	}
+	if exists, err := table.ObjectExists("dave"); err != nil || exists {
+		t.Fatalf("Expected dave not to exist: %v", err)
+	}
+	summary, err := table.GetObject("alice")
+	if err != nil {
+		t.Fatalf("Unable to get object: %v", err)
+	}
+	if summary.Id != "alice" || summary.EventCount != 2 || !summary.FirstEvent.Equal(now.Add(time.Hour)) || !summary.LastEvent.Equal(now.Add(3*time.Hour)) {
+		t.Fatalf("Unexpected summary: %+v", summary)
+	}
+	if _, err := table.GetObject("dave"); !IsNotFound(err) {
+		t.Fatalf("Expected not found: %v", err)
+	}
+}
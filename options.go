@@ -0,0 +1,158 @@
+package sky
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// A ClientOption configures a client when it is created.
+type ClientOption func(*client)
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// WithHTTPClient sets the HTTP client used for requests. The client is
+// copied, and options that configure the transport, such as WithProxy,
+// WithTLSConfig and WithDialTimeout, apply to a copy of its transport. They
+// can't be applied to a custom round tripper, in which case
+// NewClientFromURL returns an error and other constructors return a client
+// whose requests and streams fail with it. A nil client is the same as an
+// empty one.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *client) {
+		clone := &http.Client{}
+		if httpClient != nil {
+			*clone = *httpClient
+		}
+		c.httpClient = clone
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with requests and streams.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithTimeout sets the time limit for each request attempt, including
+// reading the response. It does not apply to streams.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *client) {
+		c.timeout = timeout
+	}
+}
+
+// WithDialTimeout sets the time limit for opening a connection to the server
+// for both requests and streams.
+func WithDialTimeout(timeout time.Duration) ClientOption {
+	return func(c *client) {
+		c.dialTimeout = timeout
+	}
+}
+
+// WithBasePath sets a path prefix for every request and stream, for servers
+// that are mounted below the root of a proxy.
+func WithBasePath(path string) ClientOption {
+	return func(c *client) {
+		c.basePath = cleanBasePath(path)
+	}
+}
+
+// WithProxy sets the function that selects a proxy for requests and streams,
+// such as http.ProxyURL or http.ProxyFromEnvironment. Streams are tunneled
+// through the proxy with CONNECT.
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) ClientOption {
+	return func(c *client) {
+		c.proxy = proxy
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used for HTTPS requests and
+// streams.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *client) {
+		c.tlsConfig = config
+	}
+}
+
+// WithAuthenticator sets the authenticator applied to every request and
+// stream.
+func WithAuthenticator(auth Authenticator) ClientOption {
+	return func(c *client) {
+		c.auth = auth
+	}
+}
+
+// WithRetryPolicy sets the policy for retrying failed requests.
+func WithRetryPolicy(policy *RetryPolicy) ClientOption {
+	return func(c *client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithLogger sets the logger that requests and streams are logged to, such
+// as a *slog.Logger.
+func WithLogger(logger Logger) ClientOption {
+	return func(c *client) {
+		if l, ok := logger.(*slog.Logger); ok && l == nil {
+			logger = nil
+		}
+		c.logger = logger
+	}
+}
+
+// WithLogLevel sets the level that routine messages are logged at. The
+// default is slog.LevelDebug.
+func WithLogLevel(level slog.Level) ClientOption {
+	return func(c *client) {
+		c.logLevel = level
+	}
+}
+
+// WithLogBodies includes request bodies in log messages. They are redacted
+// by default since they may contain sensitive event data.
+func WithLogBodies() ClientOption {
+	return func(c *client) {
+		c.logBodies = true
+	}
+}
+
+// WithMetrics sets the collector that request and stream metrics are
+// recorded to.
+func WithMetrics(collector Collector) ClientOption {
+	return func(c *client) {
+		c.metrics = collector
+	}
+}
+
+// WithTracer sets the tracer that creates spans for requests and streams and
+// propagates trace context to the server.
+func WithTracer(tracer Tracer) ClientOption {
+	return func(c *client) {
+		c.tracer = tracer
+	}
+}
+
+// cleanBasePath normalizes a path prefix to have a leading slash and no
+// trailing slash.
+func cleanBasePath(path string) string {
+	path = strings.TrimRight(path, "/")
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
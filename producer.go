@@ -0,0 +1,315 @@
+package sky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// Backpressure strategies used when a producer's buffer is full.
+const (
+	BlockOnFull Backpressure = iota
+	DropOnFull
+	ErrorOnFull
+)
+
+//------------------------------------------------------------------------------
+//
+// Errors
+//
+//------------------------------------------------------------------------------
+
+var (
+	// ErrProducerFull is returned by Send when the buffer is full and the
+	// backpressure strategy is ErrorOnFull.
+	ErrProducerFull = errors.New("sky.Producer: Buffer full")
+
+	// ErrProducerClosed is returned by Send after the producer is closed.
+	ErrProducerClosed = errors.New("sky.Producer: Closed")
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// A Backpressure is the strategy a producer uses when its buffer is full.
+type Backpressure int
+
+// A Producer asynchronously sends events to a table through a single event
+// stream. It is safe to call Send from multiple goroutines.
+//
+// Events are queued in a bounded buffer and written to the stream by a
+// background goroutine. The stream is flushed once a batch fills up or the
+// batch interval elapses, and committed once enough events or bytes have been
+// sent so that the server applies them regularly.
+type Producer struct {
+	stream    *TableEventStream
+	config    ProducerConfig
+	events    chan *producerEvent
+	done      chan struct{}
+	closing   chan struct{}
+	closeOnce sync.Once
+	mutex     sync.RWMutex
+	closed    bool
+	err       error
+	dropped   uint64
+}
+
+// ProducerConfig configures a producer. Zero values use the defaults.
+type ProducerConfig struct {
+	// The number of events written before the stream is flushed.
+	BatchSize int
+
+	// The maximum time buffered events wait before the stream is flushed.
+	BatchInterval time.Duration
+
+	// The number of events or bytes after which the stream is committed and
+	// a new request is started. Zero disables the limit.
+	CommitEvents int
+	CommitBytes  int
+
+	// The number of events that can be queued before backpressure applies.
+	BufferSize int
+
+	// The backpressure strategy. Defaults to BlockOnFull.
+	Backpressure Backpressure
+
+	// The stream's reconnect policy. Defaults to DefaultReconnectPolicy.
+	ReconnectPolicy *ReconnectPolicy
+
+	// An optional spool for events that can't be delivered while the server
+	// is unreachable. The caller closes the spool after the producer.
+	Spool *Spool
+
+	// Called from the producer's goroutine when events fail to send. If nil
+	// then the first error is returned from Close.
+	OnError func(error)
+}
+
+type producerEvent struct {
+	objectId string
+	event    *Event
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// NewProducer opens a stream to a table and starts a producer on it.
+func NewProducer(table Table, config *ProducerConfig) (*Producer, error) {
+	return NewProducerContext(context.Background(), table, config)
+}
+
+// NewProducerContext opens a stream to a table using the given context and
+// starts a producer on it.
+func NewProducerContext(ctx context.Context, table Table, config *ProducerConfig) (*Producer, error) {
+	if table == nil {
+		return nil, errors.New("Table required")
+	}
+	p := &Producer{done: make(chan struct{}), closing: make(chan struct{})}
+	if config != nil {
+		p.config = *config
+	}
+	if err := p.config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	stream, err := table.StreamContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream.SetReconnectPolicy(p.config.ReconnectPolicy)
+	stream.SetSpool(p.config.Spool)
+	p.stream = stream
+
+	p.events = make(chan *producerEvent, p.config.BufferSize)
+	go p.run()
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+//--------------------------------------
+// Backpressure
+//--------------------------------------
+
+// Returns the name of the strategy.
+func (b Backpressure) String() string {
+	switch b {
+	case BlockOnFull:
+		return "block"
+	case DropOnFull:
+		return "drop"
+	case ErrorOnFull:
+		return "error"
+	}
+	return fmt.Sprintf("Backpressure(%d)", int(b))
+}
+
+//--------------------------------------
+// Producer
+//--------------------------------------
+
+func (c *ProducerConfig) setDefaults() error {
+	if c.BatchSize <= 0 {
+		c.BatchSize = 1000
+	}
+	if c.BatchInterval <= 0 {
+		c.BatchInterval = time.Second
+	}
+	if c.BufferSize <= 0 {
+		c.BufferSize = 10000
+	}
+	switch c.Backpressure {
+	case BlockOnFull, DropOnFull, ErrorOnFull:
+	default:
+		return fmt.Errorf("sky.Producer: Invalid backpressure strategy: %s", c.Backpressure)
+	}
+	if c.ReconnectPolicy == nil {
+		c.ReconnectPolicy = DefaultReconnectPolicy()
+	}
+	return nil
+}
+
+// Queues an event to be sent to an object.
+func (p *Producer) Send(objectId string, event *Event) error {
+	return p.SendContext(context.Background(), objectId, event)
+}
+
+// Queues an event to be sent to an object. When blocking on a full buffer,
+// the context can be used to stop waiting. Closing the producer also stops
+// the wait and ErrProducerClosed is returned.
+func (p *Producer) SendContext(ctx context.Context, objectId string, event *Event) error {
+	if objectId == "" {
+		return errors.New("Object identifier required")
+	}
+	if event == nil {
+		return errors.New("Event required")
+	}
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.closed {
+		return ErrProducerClosed
+	}
+
+	e := &producerEvent{objectId: objectId, event: event}
+	switch p.config.Backpressure {
+	case DropOnFull, ErrorOnFull:
+		select {
+		case p.events <- e:
+			return nil
+		default:
+		}
+		if p.config.Backpressure == ErrorOnFull {
+			return ErrProducerFull
+		}
+		atomic.AddUint64(&p.dropped, 1)
+		return nil
+	default:
+		select {
+		case p.events <- e:
+			return nil
+		case <-p.closing:
+			return ErrProducerClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Retrieves the number of events dropped because the buffer was full.
+func (p *Producer) Dropped() uint64 {
+	return atomic.LoadUint64(&p.dropped)
+}
+
+// Stops accepting events, sends everything queued and closes the stream.
+func (p *Producer) Close() error {
+	return p.CloseContext(context.Background())
+}
+
+// Stops accepting events, sends everything queued and closes the stream. The
+// context limits how long to wait for queued events to be sent.
+func (p *Producer) CloseContext(ctx context.Context) error {
+	// Release any senders blocked on a full buffer so the lock can be taken.
+	p.closeOnce.Do(func() { close(p.closing) })
+	p.mutex.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.mutex.Unlock()
+
+	select {
+	case <-p.done:
+		return p.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run writes queued events to the stream until the producer is closed.
+func (p *Producer) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.config.BatchInterval)
+	defer ticker.Stop()
+
+	batched := 0
+	for {
+		select {
+		case e, ok := <-p.events:
+			if !ok {
+				p.handle(p.stream.Close())
+				return
+			}
+			p.handle(p.stream.AddEvent(e.objectId, e.event))
+
+			// Flush full batches and rotate the request when it gets large.
+			if batched++; batched >= p.config.BatchSize {
+				p.handle(p.stream.Flush())
+				batched = 0
+			}
+			count, size := p.stream.Uncommitted()
+			if (p.config.CommitEvents > 0 && count >= p.config.CommitEvents) || (p.config.CommitBytes > 0 && size >= p.config.CommitBytes) {
+				p.handle(p.stream.Commit())
+				batched = 0
+			}
+
+		case <-ticker.C:
+			if batched > 0 {
+				p.handle(p.stream.Flush())
+				batched = 0
+			}
+		}
+	}
+}
+
+// handle reports an error from the stream.
+func (p *Producer) handle(err error) {
+	if err == nil {
+		return
+	}
+	if p.config.OnError != nil {
+		p.config.OnError(err)
+	} else if p.err == nil {
+		p.err = err
+	}
+}
@@ -0,0 +1,166 @@
+package sky
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+// Ensure that events sent from many goroutines are all delivered.
+func TestProducer(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		p, err := NewProducer(table, &ProducerConfig{BatchSize: 7, CommitEvents: 25, BufferSize: 16})
+		if err != nil {
+			t.Fatalf("Failed to create producer: (%v)", err)
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					if err := p.Send("xyz", NewEvent(time.Unix(int64(i*100+j), 0), nil)); err != nil {
+						t.Errorf("Failed to send event: (%v)", err)
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if err := p.Close(); err != nil {
+			t.Fatalf("Closing producer failed: (%v)", err)
+		}
+		if err := p.Send("xyz", NewEvent(time.Now(), nil)); err != ErrProducerClosed {
+			t.Fatalf("Expected closed producer: (%v)", err)
+		}
+		events, err := table.GetEvents("xyz")
+		if err != nil || len(events) != 200 {
+			t.Fatalf("Failed to get 200 events back: %d events, (%v)", len(events), err)
+		}
+	})
+}
+
+// Ensure that a full buffer applies the configured backpressure.
+func TestProducerBackpressure(t *testing.T) {
+	for _, backpressure := range []Backpressure{DropOnFull, ErrorOnFull} {
+		p := &Producer{config: ProducerConfig{Backpressure: backpressure}, events: make(chan *producerEvent, 1)}
+		if err := p.Send("xyz", NewEvent(time.Now(), nil)); err != nil {
+			t.Fatalf("%s: Failed to send event: (%v)", backpressure, err)
+		}
+		err := p.Send("xyz", NewEvent(time.Now(), nil))
+		if backpressure == DropOnFull && (err != nil || p.Dropped() != 1) {
+			t.Fatalf("%s: Expected dropped event: %d (%v)", backpressure, p.Dropped(), err)
+		} else if backpressure == ErrorOnFull && err != ErrProducerFull {
+			t.Fatalf("%s: Expected full buffer: (%v)", backpressure, err)
+		}
+	}
+}
+
+// Ensure that an unknown backpressure strategy is rejected.
+func TestProducerInvalidBackpressure(t *testing.T) {
+	if _, err := NewProducer(NewTable(testTableName, nil), &ProducerConfig{Backpressure: Backpressure(7)}); err == nil {
+		t.Fatalf("Expected invalid backpressure error")
+	}
+}
+
+// Ensure that the stream is committed once enough events or bytes are sent.
+func TestProducerCommit(t *testing.T) {
+	// The stream opens one request, plus another after each commit.
+	tests := []struct {
+		config   *ProducerConfig
+		requests int32
+	}{
+		{&ProducerConfig{CommitEvents: 10}, 4},
+		{&ProducerConfig{CommitBytes: 1}, 31},
+	}
+	for _, test := range tests {
+		var requests int32
+		server := skytest.NewUnstartedServer()
+		handler := server.Config.Handler
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "PATCH" {
+				atomic.AddInt32(&requests, 1)
+			}
+			handler.ServeHTTP(w, r)
+		})
+		server.Start()
+
+		client := NewClientEx(server.Host(), server.Port())
+		table := NewTable(testTableName, nil)
+		client.CreateTable(table)
+		p, err := NewProducer(table, test.config)
+		if err != nil {
+			t.Fatalf("Failed to create producer: (%v)", err)
+		}
+		for i := 0; i < 30; i++ {
+			p.Send("xyz", NewEvent(time.Unix(int64(i), 0), nil))
+		}
+		if err := p.Close(); err != nil {
+			t.Fatalf("Closing producer failed: (%v)", err)
+		}
+		if n := atomic.LoadInt32(&requests); n != test.requests {
+			t.Fatalf("Expected %d requests for %+v: %d", test.requests, test.config, n)
+		}
+		server.Close()
+	}
+}
+
+// Ensure that closing the producer releases senders blocked on a full buffer
+// while the stream is stalled.
+func TestProducerCloseBlockedSend(t *testing.T) {
+	release := make(chan struct{})
+	server := skytest.NewUnstartedServer()
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PATCH" {
+			<-release
+		}
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+
+	client := NewClientEx(server.Host(), server.Port())
+	table := NewTable(testTableName, nil)
+	client.CreateTable(table)
+	p, err := NewProducer(table, &ProducerConfig{CommitEvents: 1, BufferSize: 1})
+	if err != nil {
+		t.Fatalf("Failed to create producer: (%v)", err)
+	}
+
+	// The first event stalls the producer while it waits for the commit and
+	// the second one fills the buffer.
+	p.Send("xyz", NewEvent(time.Unix(0, 0), nil))
+	for len(p.events) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	p.Send("xyz", NewEvent(time.Unix(1, 0), nil))
+
+	errs := make(chan error)
+	go func() { errs <- p.Send("xyz", NewEvent(time.Unix(2, 0), nil)) }()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.CloseContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Expected close to time out waiting for the stream: (%v)", err)
+	}
+	if err := <-errs; err != ErrProducerClosed {
+		t.Fatalf("Expected blocked send to be released: (%v)", err)
+	}
+
+	// Queued events are still sent once the server responds.
+	close(release)
+	if err := p.Close(); err != nil {
+		t.Fatalf("Closing producer failed: (%v)", err)
+	}
+	if events, err := table.GetEvents("xyz"); err != nil || len(events) != 2 {
+		t.Fatalf("Expected 2 events: %d (%v)", len(events), err)
+	}
+}
@@ -0,0 +1,405 @@
+package sky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// Field aggregations.
+const (
+	Count = "count"
+	Sum   = "sum"
+	Min   = "min"
+	Max   = "max"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// A Query is a typed representation of a Sky query. Queries are made up of a
+// list of steps which are evaluated against every event of every object.
+type Query struct {
+	table Table
+
+	// The amount of idle time between events that splits an object's events
+	// into separate sessions. Conditions do not match across sessions. It
+	// must be a whole number of seconds.
+	SessionIdleTime time.Duration
+
+	Steps []QueryStep
+}
+
+// A QueryStep is a single step in a query: either a *Selection or a
+// *Condition.
+type QueryStep interface {
+	serialize() map[string]interface{}
+	validate(properties map[string]*Property) error
+}
+
+// A Selection aggregates fields for the current event, optionally grouped by
+// one or more dimensions.
+type Selection struct {
+	Name       string
+	Dimensions []string
+	Fields     []*Field
+}
+
+// A Field is a named aggregation within a selection.
+type Field struct {
+	Name        string
+	Aggregation string
+	Property    string
+}
+
+// A Condition evaluates its steps at the first event within a range of steps
+// from the current event that matches an expression.
+type Condition struct {
+	Expression string
+	WithinMin  int
+	WithinMax  int
+	Steps      []QueryStep
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// NewQuery creates a new query against a table.
+func NewQuery(table Table) *Query {
+	return &Query{table: table}
+}
+
+// NewSelection creates a new selection step.
+func NewSelection(name string) *Selection {
+	return &Selection{Name: name}
+}
+
+// NewCondition creates a new condition step that only matches the current
+// event.
+func NewCondition(expression string) *Condition {
+	return &Condition{Expression: expression}
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+//--------------------------------------
+// Query
+//--------------------------------------
+
+// Retrieves the table the query runs against.
+func (q *Query) Table() Table {
+	return q.table
+}
+
+// Appends a selection step to the query and returns it.
+func (q *Query) Selection(name string) *Selection {
+	s := NewSelection(name)
+	q.Steps = append(q.Steps, s)
+	return s
+}
+
+// Appends a condition step to the query and returns it.
+func (q *Query) Condition(expression string) *Condition {
+	c := NewCondition(expression)
+	q.Steps = append(q.Steps, c)
+	return c
+}
+
+// Validates the query against a list of table properties.
+func (q *Query) Validate(properties []*Property) error {
+	if q.SessionIdleTime < 0 || q.SessionIdleTime%time.Second != 0 {
+		return fmt.Errorf("sky.Query: Invalid session idle time: %v", q.SessionIdleTime)
+	}
+	if len(q.Steps) == 0 {
+		return errors.New("sky.Query: At least one step required")
+	}
+
+	lookup := make(map[string]*Property)
+	for _, p := range properties {
+		lookup[p.Name] = p
+	}
+	return validateSteps(q.Steps, lookup)
+}
+
+// Executes the query after validating it against the table's properties.
+func (q *Query) Execute() (map[string]interface{}, error) {
+	return q.ExecuteContext(context.Background())
+}
+
+// Executes the query after validating it against the table's properties
+// using the given context.
+func (q *Query) ExecuteContext(ctx context.Context) (map[string]interface{}, error) {
+	if q.table == nil {
+		return nil, errors.New("sky.Query: Table required")
+	}
+	properties, err := q.table.GetPropertiesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.Validate(properties); err != nil {
+		return nil, err
+	}
+	return q.table.RawQueryContext(ctx, q.Serialize())
+}
+
+// Encodes the query into the untyped map accepted by RawQuery.
+func (q *Query) Serialize() map[string]interface{} {
+	obj := map[string]interface{}{
+		"steps": serializeSteps(q.Steps),
+	}
+	if q.SessionIdleTime > 0 {
+		obj["sessionIdleTime"] = int(q.SessionIdleTime / time.Second)
+	}
+	return obj
+}
+
+//--------------------------------------
+// Selection
+//--------------------------------------
+
+// Adds one or more dimensions to group the selection by.
+func (s *Selection) Dimension(names ...string) *Selection {
+	s.Dimensions = append(s.Dimensions, names...)
+	return s
+}
+
+// Adds a field with a given aggregation of a property.
+func (s *Selection) Field(name string, aggregation string, property string) *Selection {
+	s.Fields = append(s.Fields, &Field{Name: name, Aggregation: aggregation, Property: property})
+	return s
+}
+
+// Adds a field that counts events.
+func (s *Selection) Count(name string) *Selection {
+	return s.Field(name, Count, "")
+}
+
+// Adds a field that sums a property.
+func (s *Selection) Sum(name string, property string) *Selection {
+	return s.Field(name, Sum, property)
+}
+
+// Adds a field with the minimum value of a property.
+func (s *Selection) Min(name string, property string) *Selection {
+	return s.Field(name, Min, property)
+}
+
+// Adds a field with the maximum value of a property.
+func (s *Selection) Max(name string, property string) *Selection {
+	return s.Field(name, Max, property)
+}
+
+func (s *Selection) serialize() map[string]interface{} {
+	fields := make([]map[string]interface{}, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		fields = append(fields, map[string]interface{}{
+			"name":       f.Name,
+			"expression": f.Expression(),
+		})
+	}
+	dimensions := s.Dimensions
+	if dimensions == nil {
+		dimensions = []string{}
+	}
+
+	obj := map[string]interface{}{
+		"type":       "selection",
+		"dimensions": dimensions,
+		"fields":     fields,
+	}
+	if s.Name != "" {
+		obj["name"] = s.Name
+	}
+	return obj
+}
+
+func (s *Selection) validate(properties map[string]*Property) error {
+	if len(s.Fields) == 0 {
+		return fmt.Errorf("sky.Query: Selection requires at least one field: %s", s.Name)
+	}
+	for _, name := range s.Dimensions {
+		if properties[name] == nil {
+			return fmt.Errorf("sky.Query: Invalid dimension: %s", name)
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, f := range s.Fields {
+		if err := f.validate(properties); err != nil {
+			return err
+		}
+		if names[f.Name] {
+			return fmt.Errorf("sky.Query: Duplicate field: %s", f.Name)
+		}
+		names[f.Name] = true
+	}
+	return nil
+}
+
+//--------------------------------------
+// Field
+//--------------------------------------
+
+// The field's expression, e.g. "sum(purchase_price)".
+func (f *Field) Expression() string {
+	return fmt.Sprintf("%s(%s)", f.Aggregation, f.Property)
+}
+
+func (f *Field) validate(properties map[string]*Property) error {
+	if f.Name == "" {
+		return errors.New("sky.Query: Field name required")
+	}
+
+	switch f.Aggregation {
+	case Count:
+		if f.Property != "" {
+			return fmt.Errorf("sky.Query: Count does not take a property: %s", f.Name)
+		}
+		return nil
+	case Sum, Min, Max:
+	default:
+		return fmt.Errorf("sky.Query: Invalid aggregation: %s", f.Aggregation)
+	}
+
+	p := properties[f.Property]
+	if p == nil {
+		return fmt.Errorf("sky.Query: Invalid field property: %s", f.Property)
+	}
+	if p.DataType != Integer && p.DataType != Float {
+		return fmt.Errorf("sky.Query: Cannot %s a %s property: %s", f.Aggregation, p.DataType, f.Property)
+	}
+	return nil
+}
+
+//--------------------------------------
+// Condition
+//--------------------------------------
+
+// Sets the range of steps from the current event that the condition is
+// checked against.
+func (c *Condition) Within(min int, max int) *Condition {
+	c.WithinMin, c.WithinMax = min, max
+	return c
+}
+
+// Appends a nested selection step to the condition and returns it.
+func (c *Condition) Selection(name string) *Selection {
+	s := NewSelection(name)
+	c.Steps = append(c.Steps, s)
+	return s
+}
+
+// Appends a nested condition step to the condition and returns it.
+func (c *Condition) Condition(expression string) *Condition {
+	child := NewCondition(expression)
+	c.Steps = append(c.Steps, child)
+	return child
+}
+
+func (c *Condition) serialize() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "condition",
+		"expression":  c.Expression,
+		"within":      []int{c.WithinMin, c.WithinMax},
+		"withinUnits": "steps",
+		"steps":       serializeSteps(c.Steps),
+	}
+}
+
+func (c *Condition) validate(properties map[string]*Property) error {
+	if c.Expression == "" {
+		return errors.New("sky.Query: Condition expression required")
+	}
+	for _, name := range expressionIdentifiers(c.Expression) {
+		if properties[name] == nil {
+			return fmt.Errorf("sky.Query: Invalid condition property: %s", name)
+		}
+	}
+	if c.WithinMin < 0 || c.WithinMax < c.WithinMin {
+		return fmt.Errorf("sky.Query: Invalid within range: %d..%d", c.WithinMin, c.WithinMax)
+	}
+	if len(c.Steps) == 0 {
+		return fmt.Errorf("sky.Query: Condition requires at least one step: %s", c.Expression)
+	}
+	return validateSteps(c.Steps, properties)
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+func serializeSteps(steps []QueryStep) []map[string]interface{} {
+	output := make([]map[string]interface{}, 0, len(steps))
+	for _, step := range steps {
+		output = append(output, step.serialize())
+	}
+	return output
+}
+
+func validateSteps(steps []QueryStep, properties map[string]*Property) error {
+	for _, step := range steps {
+		if step == nil {
+			return errors.New("sky.Query: Invalid nil step")
+		}
+		if err := step.validate(properties); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expressionIdentifiers returns the property names referenced by an
+// expression. Quoted strings, numbers and keywords are skipped.
+func expressionIdentifiers(expression string) []string {
+	var names []string
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		ch := runes[i]
+		switch {
+		case ch == '"' || ch == '\'':
+			for i++; i < len(runes) && runes[i] != ch; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+			i++
+		case unicode.IsLetter(ch) || ch == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			switch name := string(runes[start:i]); name {
+			case "true", "false", "and", "or", "not":
+			default:
+				names = append(names, name)
+			}
+		case unicode.IsDigit(ch):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e') {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return names
+}
@@ -0,0 +1,94 @@
+package sky
+
+import (
+	"testing"
+	"time"
+)
+
+// Ensure that a query serializes into the raw query format.
+func TestQuerySerialize(t *testing.T) {
+	q := NewQuery(nil)
+	q.SessionIdleTime = 2 * time.Hour
+	q.Condition("action == 'A0'").Within(1, 2).Selection("next").Dimension("action").Count("count")
+	q.Selection("").Sum("total", "price")
+
+	obj := q.Serialize()
+	if obj["sessionIdleTime"] != 7200 {
+		t.Fatalf("Invalid session idle time: %v", obj["sessionIdleTime"])
+	}
+	steps := obj["steps"].([]map[string]interface{})
+	if len(steps) != 2 || steps[0]["type"] != "condition" || steps[1]["type"] != "selection" {
+		t.Fatalf("Invalid steps: %v", steps)
+	}
+	if within := steps[0]["within"].([]int); within[0] != 1 || within[1] != 2 || steps[0]["withinUnits"] != "steps" {
+		t.Fatalf("Invalid within: %v", steps[0])
+	}
+	nested := steps[0]["steps"].([]map[string]interface{})[0]
+	if nested["name"] != "next" || nested["dimensions"].([]string)[0] != "action" {
+		t.Fatalf("Invalid nested selection: %v", nested)
+	}
+	if field := steps[1]["fields"].([]map[string]interface{})[0]; field["name"] != "total" || field["expression"] != "sum(price)" {
+		t.Fatalf("Invalid field: %v", field)
+	}
+}
+
+// Ensure that a query is validated against the table's properties.
+func TestQueryValidate(t *testing.T) {
+	properties := []*Property{NewProperty("action", false, Factor), NewProperty("price", false, Float)}
+	tests := []struct {
+		build func(q *Query)
+		valid bool
+	}{
+		{func(q *Query) { q.Selection("").Count("count") }, true},
+		{func(q *Query) { q.Selection("").Dimension("action").Max("max", "price") }, true},
+		{func(q *Query) { q.Condition("action == \"x\"").Within(0, 2).Selection("").Count("count") }, true},
+		{func(q *Query) {}, false},
+		{func(q *Query) { q.Selection("").Dimension("foo").Count("count") }, false},
+		{func(q *Query) { q.Selection("").Sum("total", "action") }, false},
+		{func(q *Query) { q.Selection("").Field("x", "avg", "price") }, false},
+		{func(q *Query) { q.Selection("").Count("count").Count("count") }, false},
+		{func(q *Query) { q.Condition("foo == 'action'").Selection("").Count("count") }, false},
+		{func(q *Query) { q.Condition("action == 'x'").Within(2, 1).Selection("").Count("count") }, false},
+		{func(q *Query) { q.SessionIdleTime = 500 * time.Millisecond; q.Selection("").Count("count") }, false},
+	}
+	for i, test := range tests {
+		q := NewQuery(nil)
+		test.build(q)
+		if err := q.Validate(properties); (err == nil) != test.valid {
+			t.Fatalf("Test #%d: unexpected validation result: %v", i, err)
+		}
+	}
+}
+
+// Ensure that a query can be executed against the server.
+func TestQueryExecute(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		table.CreateProperty(NewProperty("action", false, Factor))
+		t0, _ := ParseTimestamp("1970-01-01T00:00:00Z")
+		t1, _ := ParseTimestamp("1970-01-01T00:00:01Z")
+		t2, _ := ParseTimestamp("1970-01-01T00:00:02Z")
+		table.AddEvent("o0", NewEvent(t0, map[string]interface{}{"action": "A0"}), Replace)
+		table.AddEvent("o0", NewEvent(t1, map[string]interface{}{"action": "A1"}), Replace)
+		table.AddEvent("o0", NewEvent(t2, map[string]interface{}{"action": "A1"}), Replace)
+
+		q := NewQuery(table)
+		q.Selection("").Dimension("action").Count("count")
+		results, err := q.Execute()
+		if err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		actions, _ := results["action"].(map[string]interface{})
+		a0, _ := actions["A0"].(map[string]interface{})
+		a1, _ := actions["A1"].(map[string]interface{})
+		if a0["count"] != float64(1) || a1["count"] != float64(2) {
+			t.Fatalf("Invalid query results: %v", results)
+		}
+
+		// Unknown properties are rejected before the query is sent.
+		q = NewQuery(table)
+		q.Selection("").Dimension("gender").Count("count")
+		if _, err := q.Execute(); err == nil {
+			t.Fatalf("Expected validation error")
+		}
+	})
+}
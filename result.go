@@ -0,0 +1,389 @@
+package sky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// A resultRow is a single flattened row of a selection's results, made up of
+// one value per dimension and one value per field.
+type resultRow map[string]interface{}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// Executes the query and decodes the results of its only selection into v.
+// See Selection.Decode for the decoding rules.
+func (q *Query) ExecuteInto(v interface{}) error {
+	return q.ExecuteIntoContext(context.Background(), v)
+}
+
+// Executes the query using the given context and decodes the results of its
+// only selection into v. See Selection.Decode for the decoding rules.
+func (q *Query) ExecuteIntoContext(ctx context.Context, v interface{}) error {
+	selections := findSelections(q.Steps)
+	if len(selections) != 1 {
+		return fmt.Errorf("sky.Query: Decoding requires exactly one selection, found %d", len(selections))
+	}
+	if q.table == nil {
+		return errors.New("sky.Query: Table required")
+	}
+
+	properties, err := q.table.GetPropertiesContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := q.Validate(properties); err != nil {
+		return err
+	}
+	results, err := q.table.RawQueryContext(ctx, q.Serialize())
+	if err != nil {
+		return err
+	}
+	return selections[0].Decode(results, properties, v)
+}
+
+// Decodes the selection's part of a query result into v. The result is
+// flattened into one row per combination of dimension values.
+//
+// v must be a pointer to a struct or to a slice of structs. A struct can only
+// receive a result with at most one row. Struct fields are matched to
+// dimensions and fields by their `sky:"name"` tag, or case-insensitively by
+// field name when there is no tag. A tag of "-" skips the field.
+//
+// Values are converted according to the data type of the matching property.
+// Counts are integers.
+func (s *Selection) Decode(results map[string]interface{}, properties []*Property, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("sky.Query: Decode requires a non-nil pointer")
+	}
+
+	lookup := make(map[string]*Property)
+	for _, p := range properties {
+		lookup[p.Name] = p
+	}
+	rows, err := s.rows(results, lookup)
+	if err != nil {
+		return err
+	}
+
+	elem := rv.Elem()
+	switch {
+	case elem.Kind() == reflect.Slice && derefType(elem.Type().Elem()).Kind() == reflect.Struct:
+		slice := reflect.MakeSlice(elem.Type(), 0, len(rows))
+		for _, row := range rows {
+			item := reflect.New(derefType(elem.Type().Elem())).Elem()
+			if err := decodeRow(row, item); err != nil {
+				return err
+			}
+			if elem.Type().Elem().Kind() == reflect.Ptr {
+				item = item.Addr()
+			}
+			slice = reflect.Append(slice, item)
+		}
+		elem.Set(slice)
+		return nil
+
+	case elem.Kind() == reflect.Struct:
+		if len(rows) > 1 {
+			return fmt.Errorf("sky.Query: Cannot decode %d rows into a struct", len(rows))
+		} else if len(rows) == 0 {
+			return nil
+		}
+		return decodeRow(rows[0], elem)
+	}
+	return fmt.Errorf("sky.Query: Cannot decode into %s", rv.Type())
+}
+
+// rows flattens the selection's results into rows.
+func (s *Selection) rows(results map[string]interface{}, properties map[string]*Property) ([]resultRow, error) {
+	if s.Name != "" {
+		results, _ = results[s.Name].(map[string]interface{})
+	}
+	if results == nil {
+		return nil, nil
+	}
+	return s.flatten(results, 0, resultRow{}, properties)
+}
+
+func (s *Selection) flatten(results map[string]interface{}, depth int, row resultRow, properties map[string]*Property) ([]resultRow, error) {
+	// Once all dimensions are resolved then read the fields.
+	if depth == len(s.Dimensions) {
+		output := resultRow{}
+		for k, v := range row {
+			output[k] = v
+		}
+		for _, f := range s.Fields {
+			if _, ok := output[f.Name]; ok {
+				return nil, fmt.Errorf("sky.Query: Result name collision: %s", f.Name)
+			}
+			value, err := f.decode(results[f.Name], properties)
+			if err != nil {
+				return nil, err
+			}
+			output[f.Name] = value
+		}
+		return []resultRow{output}, nil
+	}
+
+	// Otherwise descend into each value of the current dimension.
+	name := s.Dimensions[depth]
+	if _, ok := row[name]; ok {
+		return nil, fmt.Errorf("sky.Query: Result name collision: %s", name)
+	}
+	values, _ := results[name].(map[string]interface{})
+	keys := make([]interface{}, 0, len(values))
+	decoded := make(map[interface{}]string)
+	for key := range values {
+		value, err := decodeDimension(key, properties[name])
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := decoded[value]; ok {
+			return nil, fmt.Errorf("sky.Query: Result name collision: %s=%s and %s=%s", name, other, name, key)
+		}
+		keys = append(keys, value)
+		decoded[value] = key
+	}
+	sort.Slice(keys, func(i, j int) bool { return lessValue(keys[i], keys[j]) })
+
+	var rows []resultRow
+	for _, key := range keys {
+		child, ok := values[decoded[key]].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("sky.Query: Invalid result for %s=%s", name, decoded[key])
+		}
+		row[name] = key
+		tmp, err := s.flatten(child, depth+1, row, properties)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, tmp...)
+	}
+	delete(row, name)
+	return rows, nil
+}
+
+// decode converts a raw field result to the Go type matching the field's
+// property data type.
+func (f *Field) decode(v interface{}, properties map[string]*Property) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	n, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("sky.Query: Invalid numeric result for %s: %v", f.Name, v)
+	}
+	if f.Aggregation == Count {
+		return int64(n), nil
+	}
+	if p := properties[f.Property]; p != nil && p.DataType == Integer {
+		return int64(n), nil
+	}
+	return n, nil
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// findSelections returns all selections within a list of steps.
+func findSelections(steps []QueryStep) []*Selection {
+	var selections []*Selection
+	for _, step := range steps {
+		switch step := step.(type) {
+		case *Selection:
+			selections = append(selections, step)
+		case *Condition:
+			selections = append(selections, findSelections(step.Steps)...)
+		}
+	}
+	return selections
+}
+
+// decodeDimension converts a dimension key to the Go type matching the
+// property's data type. Blank keys for non-string types decode to nil.
+func decodeDimension(key string, p *Property) (interface{}, error) {
+	if p == nil || p.DataType == String || p.DataType == Factor {
+		return key, nil
+	} else if key == "" {
+		return nil, nil
+	}
+	switch p.DataType {
+	case Integer:
+		f, err := strconv.ParseFloat(key, 64)
+		if err != nil {
+			return nil, fmt.Errorf("sky.Query: Invalid integer dimension for %s: %s", p.Name, key)
+		}
+		return int64(f), nil
+	case Float:
+		f, err := strconv.ParseFloat(key, 64)
+		if err != nil {
+			return nil, fmt.Errorf("sky.Query: Invalid float dimension for %s: %s", p.Name, key)
+		}
+		return f, nil
+	case Boolean:
+		b, err := strconv.ParseBool(key)
+		if err != nil {
+			return nil, fmt.Errorf("sky.Query: Invalid boolean dimension for %s: %s", p.Name, key)
+		}
+		return b, nil
+	}
+	return key, nil
+}
+
+// decodeRow sets the fields of a struct value from a row.
+func decodeRow(row resultRow, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name, ok := fieldName(sf)
+		if !ok {
+			continue
+		}
+
+		// Look up by tag name exactly, otherwise by field name loosely.
+		value, exists := row[name]
+		if _, tagged := sf.Tag.Lookup("sky"); !exists && !tagged {
+			for k, tmp := range row {
+				if strings.EqualFold(k, name) {
+					value, exists = tmp, true
+					break
+				}
+			}
+		}
+		if !exists || value == nil {
+			continue
+		}
+		if err := assignValue(v.Field(i), value); err != nil {
+			return fmt.Errorf("sky: Cannot decode %s into %s.%s: %v", name, t.Name(), sf.Name, err)
+		}
+	}
+	return nil
+}
+
+// fieldName returns the sky name for a struct field. Returns false if the
+// field is skipped.
+func fieldName(sf reflect.StructField) (string, bool) {
+	tag := sf.Tag.Get("sky")
+	if tag == "-" {
+		return "", false
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, true
+	}
+	return sf.Name, true
+}
+
+// assignValue sets a reflected value from a decoded string, int64, float64 or
+// bool, converting between compatible kinds.
+func assignValue(dst reflect.Value, value interface{}) error {
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		dst = dst.Elem()
+	}
+
+	src := reflect.ValueOf(value)
+	switch dst.Kind() {
+	case reflect.Interface:
+		if src.Type().AssignableTo(dst.Type()) {
+			dst.Set(src)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch value := value.(type) {
+		case int64:
+			dst.SetInt(value)
+			return nil
+		case float64:
+			if value != math.Trunc(value) {
+				return fmt.Errorf("%v is not an integer", value)
+			}
+			dst.SetInt(int64(value))
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch value := value.(type) {
+		case int64:
+			if value >= 0 {
+				dst.SetUint(uint64(value))
+				return nil
+			}
+		case float64:
+			if value >= 0 && value == math.Trunc(value) {
+				dst.SetUint(uint64(value))
+				return nil
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		switch value := value.(type) {
+		case int64:
+			dst.SetFloat(float64(value))
+			return nil
+		case float64:
+			dst.SetFloat(value)
+			return nil
+		}
+	case reflect.String:
+		if value, ok := value.(string); ok {
+			dst.SetString(value)
+			return nil
+		}
+	case reflect.Bool:
+		if value, ok := value.(bool); ok {
+			dst.SetBool(value)
+			return nil
+		}
+	}
+	return fmt.Errorf("incompatible value %v (%T)", value, value)
+}
+
+// derefType returns the element type of a pointer type.
+func derefType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// lessValue orders two decoded dimension values of the same type.
+func lessValue(a, b interface{}) bool {
+	switch a := a.(type) {
+	case int64:
+		if b, ok := b.(int64); ok {
+			return a < b
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return a < b
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			return !a && b
+		}
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
@@ -0,0 +1,107 @@
+package sky
+
+import (
+	"testing"
+)
+
+// Ensure that nested dimensions are flattened into typed rows.
+func TestSelectionDecode(t *testing.T) {
+	properties := []*Property{
+		NewProperty("gender", false, Factor),
+		NewProperty("age", false, Integer),
+		NewProperty("price", false, Float),
+	}
+	results := map[string]interface{}{
+		"gender": map[string]interface{}{
+			"m": map[string]interface{}{
+				"age": map[string]interface{}{
+					"30": map[string]interface{}{"count": float64(2), "total": float64(10.5)},
+					"4":  map[string]interface{}{"count": float64(1), "total": float64(3)},
+				},
+			},
+			"f": map[string]interface{}{
+				"age": map[string]interface{}{
+					"20": map[string]interface{}{"count": float64(5), "total": float64(1.25)},
+				},
+			},
+		},
+	}
+	s := NewSelection("").Dimension("gender", "age").Count("count").Sum("total", "price")
+
+	var rows []struct {
+		Gender string
+		Age    int
+		Count  int
+		Total  float64 `sky:"total"`
+		Other  string  `sky:"-"`
+	}
+	if err := s.Decode(results, properties, &rows); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 rows: %v", rows)
+	}
+	if r := rows[0]; r.Gender != "f" || r.Age != 20 || r.Count != 5 || r.Total != 1.25 {
+		t.Fatalf("Invalid row(0): %v", r)
+	}
+	if r := rows[1]; r.Gender != "m" || r.Age != 4 || r.Count != 1 || r.Total != 3 {
+		t.Fatalf("Invalid row(1): %v", r)
+	}
+	if r := rows[2]; r.Gender != "m" || r.Age != 30 || r.Count != 2 || r.Total != 10.5 {
+		t.Fatalf("Invalid row(2): %v", r)
+	}
+
+	// A fractional float cannot be decoded into an integer field.
+	var bad []struct{ Total int }
+	if err := s.Decode(results, properties, &bad); err == nil {
+		t.Fatalf("Expected conversion error")
+	}
+}
+
+// Ensure that flattened names that collide are rejected.
+func TestSelectionDecodeCollision(t *testing.T) {
+	properties := []*Property{NewProperty("age", false, Integer)}
+	results := map[string]interface{}{
+		"age": map[string]interface{}{
+			"30":   map[string]interface{}{"count": float64(2)},
+			"30.0": map[string]interface{}{"count": float64(1)},
+		},
+	}
+	var rows []struct{ Age, Count int }
+	if err := NewSelection("").Dimension("age").Count("count").Decode(results, properties, &rows); err == nil {
+		t.Fatalf("Expected collision between dimension values")
+	}
+
+	results = map[string]interface{}{
+		"age": map[string]interface{}{
+			"30": map[string]interface{}{"age": float64(2)},
+		},
+	}
+	if err := NewSelection("").Dimension("age").Count("age").Decode(results, properties, &rows); err == nil {
+		t.Fatalf("Expected collision between dimension and field")
+	}
+}
+
+// Ensure that a query without dimensions can be decoded into a struct.
+func TestQueryExecuteInto(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		table.CreateProperty(NewProperty("price", false, Integer))
+		t0, _ := ParseTimestamp("1970-01-01T00:00:00Z")
+		t1, _ := ParseTimestamp("1970-01-01T00:00:01Z")
+		table.AddEvent("o0", NewEvent(t0, map[string]interface{}{"price": 10}), Replace)
+		table.AddEvent("o1", NewEvent(t1, map[string]interface{}{"price": 20}), Replace)
+
+		var result struct {
+			Count int
+			Max   int64 `sky:"max_price"`
+		}
+		q := NewQuery(table)
+		q.Selection("totals").Count("count").Max("max_price", "price")
+		if err := q.ExecuteInto(&result); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		if result.Count != 2 || result.Max != 20 {
+			t.Fatalf("Invalid result: %v", result)
+		}
+	})
+}
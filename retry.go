@@ -0,0 +1,158 @@
+package sky
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// A RetryPolicy controls how a client retries failed requests.
+type RetryPolicy struct {
+	// The maximum number of attempts, including the first one.
+	MaxAttempts int
+
+	// The delay before the first retry. The delay doubles on each following
+	// retry, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// The fraction of each delay, from 0 to 1, that is randomized.
+	Jitter float64
+
+	// The HTTP methods that are retried. Defaults to GET, PUT and DELETE
+	// when empty. PATCH and POST are not idempotent so they are only retried
+	// if they are added explicitly.
+	Methods []string
+
+	// The response status codes that are retried. Defaults to 500, 502, 503
+	// and 504 when empty.
+	StatusCodes []int
+
+	// Determines whether a transport error is retried. Defaults to errors
+	// matching ErrUnavailable, which excludes context cancellation.
+	RetryableError func(error) bool
+}
+
+// The methods and status codes retried when a policy doesn't list any.
+var (
+	defaultRetryMethods     = []string{"GET", "PUT", "DELETE"}
+	defaultRetryStatusCodes = []int{
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// DefaultRetryPolicy returns a policy that retries idempotent requests up to
+// three times on connection failures and 5xx responses.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     100 * time.Millisecond,
+		MaxBackoff:  2 * time.Second,
+		Jitter:      0.5,
+		Methods:     append([]string(nil), defaultRetryMethods...),
+		StatusCodes: append([]int(nil), defaultRetryStatusCodes...),
+	}
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// Determines whether a failed request can be retried.
+func (p *RetryPolicy) retryable(method string, err error) bool {
+	methods := p.Methods
+	if len(methods) == 0 {
+		methods = defaultRetryMethods
+	}
+	if !containsString(methods, method) {
+		return false
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	if e.StatusCode != 0 {
+		statusCodes := p.StatusCodes
+		if len(statusCodes) == 0 {
+			statusCodes = defaultRetryStatusCodes
+		}
+		for _, code := range statusCodes {
+			if code == e.StatusCode {
+				return true
+			}
+		}
+		return false
+	}
+	if p.RetryableError != nil {
+		return p.RetryableError(e.Err)
+	}
+	return IsUnavailable(e)
+}
+
+// Returns the delay before a given retry, including jitter.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := backoff(p.Backoff, p.MaxBackoff, attempt)
+	if p.Jitter > 0 && d > 0 {
+		j := time.Duration(float64(d) * p.Jitter * rand.Float64())
+		d = d - time.Duration(float64(d)*p.Jitter/2) + j
+	}
+	return d
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// backoff returns an exponentially increasing delay for a given attempt.
+func backoff(base time.Duration, max time.Duration, attempt int) time.Duration {
+	d := base
+	for i := 0; i < attempt && (max <= 0 || d < max); i++ {
+		d *= 2
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+	return d
+}
+
+// sleep waits for a duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package sky
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+// newFlakyServer starts a fake server that responds to the first n requests
+// with a given status code.
+func newFlakyServer(n int32, status int) (*skytest.Server, *int32) {
+	var count int32
+	server := skytest.NewUnstartedServer()
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= n {
+			w.WriteHeader(status)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	return server, &count
+}
+
+// Ensure that idempotent requests are retried.
+func TestRetryPolicy(t *testing.T) {
+	server, count := newFlakyServer(2, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	client := NewClientEx(server.Host(), server.Port())
+	policy := DefaultRetryPolicy()
+	policy.Backoff = time.Millisecond
+	client.SetRetryPolicy(policy)
+
+	if _, err := client.GetTables(); err != nil {
+		t.Fatalf("Expected retried request to succeed: %v", err)
+	}
+	if atomic.LoadInt32(count) != 3 {
+		t.Fatalf("Expected 3 attempts: %d", atomic.LoadInt32(count))
+	}
+}
+
+// Ensure that non-idempotent requests are not retried unless opted in.
+func TestRetryPolicyMethods(t *testing.T) {
+	server, count := newFlakyServer(1, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	client := NewClientEx(server.Host(), server.Port())
+	policy := DefaultRetryPolicy()
+	policy.Backoff = time.Millisecond
+	client.SetRetryPolicy(policy)
+
+	if err := client.CreateTable(NewTable(testTableName, nil)); !IsUnavailable(err) || atomic.LoadInt32(count) != 1 {
+		t.Fatalf("Expected a single failed attempt: %d (%v)", atomic.LoadInt32(count), err)
+	}
+
+	policy.Methods = append(policy.Methods, "POST")
+	atomic.StoreInt32(count, 0)
+	if err := client.CreateTable(NewTable(testTableName, nil)); err != nil || atomic.LoadInt32(count) != 2 {
+		t.Fatalf("Expected retried request to succeed: %d (%v)", atomic.LoadInt32(count), err)
+	}
+
+	// A policy without methods retries the default idempotent methods.
+	client.SetRetryPolicy(&RetryPolicy{MaxAttempts: 2, StatusCodes: []int{http.StatusServiceUnavailable}})
+	atomic.StoreInt32(count, 0)
+	if _, err := client.GetTables(); err != nil || atomic.LoadInt32(count) != 2 {
+		t.Fatalf("Expected retried request to succeed: %d (%v)", atomic.LoadInt32(count), err)
+	}
+}
+
+// Ensure that client errors are not retried.
+func TestRetryPolicyStatusCodes(t *testing.T) {
+	server, count := newFlakyServer(1, http.StatusNotFound)
+	defer server.Close()
+
+	client := NewClientEx(server.Host(), server.Port())
+	client.SetRetryPolicy(DefaultRetryPolicy())
+	if _, err := client.GetTables(); !IsNotFound(err) || atomic.LoadInt32(count) != 1 {
+		t.Fatalf("Expected a single failed attempt: %d (%v)", atomic.LoadInt32(count), err)
+	}
+
+	// A policy without status codes retries the default server errors.
+	server, count = newFlakyServer(1, http.StatusInternalServerError)
+	defer server.Close()
+	client = NewClientEx(server.Host(), server.Port())
+	client.SetRetryPolicy(&RetryPolicy{MaxAttempts: 2})
+	if _, err := client.GetTables(); err != nil || atomic.LoadInt32(count) != 2 {
+		t.Fatalf("Expected retried request to succeed: %d (%v)", atomic.LoadInt32(count), err)
+	}
+}
@@ -0,0 +1,351 @@
+package sky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// Schema change actions.
+const (
+	CreateTableAction    = "create table"
+	CreatePropertyAction = "create property"
+	RenamePropertyAction = "rename property"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// A Schema declares a table and its properties so that they can be kept in
+// sync with the server.
+type Schema struct {
+	Table      string
+	Properties []*SchemaProperty
+}
+
+// A SchemaProperty declares a single property of a schema.
+type SchemaProperty struct {
+	Name      string
+	Transient bool
+	DataType  string
+
+	// Previous names of the property. If the table has a property under one
+	// of these names then it is renamed instead of creating a new one.
+	PreviousNames []string
+}
+
+// SyncOptions controls how a schema is synced.
+type SyncOptions struct {
+	// If set, the plan is computed and printed but not applied.
+	DryRun bool
+
+	// If set, the plan is printed here before it is applied. Dry runs print
+	// to stdout when it is nil.
+	Writer io.Writer
+}
+
+// A SyncPlan lists the changes needed to bring the server in line with a
+// schema.
+type SyncPlan struct {
+	Table     string
+	Changes   []*SchemaChange
+	Conflicts []*SchemaConflict
+
+	// Properties on the server which are not declared in the schema. These
+	// are left untouched.
+	Undeclared []*Property
+}
+
+// A SchemaChange is a single change in a sync plan.
+type SchemaChange struct {
+	Action   string
+	Property *Property
+	From     string
+}
+
+// A SchemaConflict is a declared property which differs from the server's
+// property in a way that cannot be changed.
+type SchemaConflict struct {
+	Name     string
+	Declared *SchemaProperty
+	Actual   *Property
+}
+
+// A SchemaConflictError is returned when a schema conflicts with the server.
+// No changes are applied when there are conflicts.
+type SchemaConflictError struct {
+	Conflicts []*SchemaConflict
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// NewSchema creates a schema for a table.
+func NewSchema(table string) *Schema {
+	return &Schema{Table: table}
+}
+
+// NewSchemaFromStruct creates a schema for a table from the properties of a
+// tagged struct. See MarshalEvent for the tag format.
+func NewSchemaFromStruct(table string, v interface{}) (*Schema, error) {
+	properties, err := StructProperties(v)
+	if err != nil {
+		return nil, err
+	}
+	s := NewSchema(table)
+	for _, p := range properties {
+		s.Property(p.Name, p.Transient, p.DataType)
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+//--------------------------------------
+// Schema
+//--------------------------------------
+
+// Declares a property on the schema and returns it.
+func (s *Schema) Property(name string, transient bool, dataType string) *SchemaProperty {
+	p := &SchemaProperty{Name: name, Transient: transient, DataType: dataType}
+	s.Properties = append(s.Properties, p)
+	return p
+}
+
+// Validates the schema declaration.
+func (s *Schema) Validate() error {
+	if s.Table == "" {
+		return errors.New("sky.Schema: Table name required")
+	}
+	names := make(map[string]bool)
+	for _, p := range s.Properties {
+		if p.Name == "" {
+			return errors.New("sky.Schema: Property name required")
+		}
+		switch p.DataType {
+		case String, Integer, Float, Boolean, Factor:
+		default:
+			return fmt.Errorf("sky.Schema: Invalid data type for %s: %s", p.Name, p.DataType)
+		}
+		for _, name := range append([]string{p.Name}, p.PreviousNames...) {
+			if names[name] {
+				return fmt.Errorf("sky.Schema: Duplicate property name: %s", name)
+			}
+			names[name] = true
+		}
+	}
+	return nil
+}
+
+// Computes the changes needed to bring the server in line with the schema.
+func (s *Schema) Plan(client Client) (*SyncPlan, error) {
+	return s.PlanContext(context.Background(), client)
+}
+
+// Computes the changes needed to bring the server in line with the schema
+// using the given context.
+func (s *Schema) PlanContext(ctx context.Context, client Client) (*SyncPlan, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	plan := &SyncPlan{Table: s.Table}
+
+	// Retrieve existing properties if the table exists.
+	table, err := s.findTable(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	var properties []*Property
+	if table == nil {
+		plan.Changes = append(plan.Changes, &SchemaChange{Action: CreateTableAction})
+	} else if properties, err = table.GetPropertiesContext(ctx); err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*Property)
+	for _, p := range properties {
+		existing[p.Name] = p
+	}
+
+	declared := make(map[string]bool)
+	for _, sp := range s.Properties {
+		declared[sp.Name] = true
+		for _, name := range sp.PreviousNames {
+			declared[name] = true
+		}
+
+		// Find the property under its current or a previous name. A
+		// property found under more than one name can't be renamed.
+		actual, from, duplicate := existing[sp.Name], "", false
+		for _, name := range sp.PreviousNames {
+			if p := existing[name]; p != nil {
+				if actual != nil {
+					plan.Conflicts = append(plan.Conflicts, &SchemaConflict{Name: sp.Name, Declared: sp, Actual: p})
+					duplicate = true
+				}
+				actual, from = p, name
+			}
+		}
+		if duplicate {
+			continue
+		}
+
+		property := NewProperty(sp.Name, sp.Transient, sp.DataType)
+		switch {
+		case actual == nil:
+			plan.Changes = append(plan.Changes, &SchemaChange{Action: CreatePropertyAction, Property: property})
+		case actual.DataType != sp.DataType || actual.Transient != sp.Transient:
+			plan.Conflicts = append(plan.Conflicts, &SchemaConflict{Name: sp.Name, Declared: sp, Actual: actual})
+		case from != "":
+			plan.Changes = append(plan.Changes, &SchemaChange{Action: RenamePropertyAction, Property: property, From: from})
+		}
+	}
+
+	for _, p := range properties {
+		if !declared[p.Name] {
+			plan.Undeclared = append(plan.Undeclared, p)
+		}
+	}
+	return plan, nil
+}
+
+// Syncs the schema with the server. Missing tables and properties are
+// created and renamed properties are updated. Nothing is applied if any
+// declared property conflicts with the server; a *SchemaConflictError is
+// returned along with the plan instead.
+func (s *Schema) Sync(client Client, options *SyncOptions) (*SyncPlan, error) {
+	return s.SyncContext(context.Background(), client, options)
+}
+
+// Syncs the schema with the server using the given context.
+func (s *Schema) SyncContext(ctx context.Context, client Client, options *SyncOptions) (*SyncPlan, error) {
+	if options == nil {
+		options = &SyncOptions{}
+	}
+	plan, err := s.PlanContext(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	w := options.Writer
+	if w == nil && options.DryRun {
+		w = os.Stdout
+	}
+	if w != nil {
+		if _, err := io.WriteString(w, plan.String()); err != nil {
+			return plan, err
+		}
+	}
+	if len(plan.Conflicts) > 0 {
+		return plan, &SchemaConflictError{Conflicts: plan.Conflicts}
+	}
+	if options.DryRun {
+		return plan, nil
+	}
+	return plan, plan.apply(ctx, client)
+}
+
+// findTable retrieves the schema's table or nil if it doesn't exist.
+func (s *Schema) findTable(ctx context.Context, client Client) (Table, error) {
+	table, err := client.GetTableContext(ctx, s.Table)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	return table, err
+}
+
+//--------------------------------------
+// Plan
+//--------------------------------------
+
+// apply executes the plan's changes in order.
+func (p *SyncPlan) apply(ctx context.Context, client Client) error {
+	table := NewTable(p.Table, client)
+	for _, c := range p.Changes {
+		var err error
+		switch c.Action {
+		case CreateTableAction:
+			err = client.CreateTableContext(ctx, table)
+		case CreatePropertyAction:
+			err = table.CreatePropertyContext(ctx, NewProperty(c.Property.Name, c.Property.Transient, c.Property.DataType))
+		case RenamePropertyAction:
+			err = table.UpdatePropertyContext(ctx, c.From, NewProperty(c.Property.Name, c.Property.Transient, c.Property.DataType))
+		}
+		if err != nil {
+			return fmt.Errorf("sky.Schema: Unable to %s: %v", c, err)
+		}
+	}
+	return nil
+}
+
+// Returns true if the plan has no changes or conflicts.
+func (p *SyncPlan) Empty() bool {
+	return len(p.Changes) == 0 && len(p.Conflicts) == 0
+}
+
+// Formats the plan with one line per change, conflict or undeclared property.
+func (p *SyncPlan) String() string {
+	var b strings.Builder
+	for _, c := range p.Changes {
+		fmt.Fprintf(&b, "%s\n", c)
+	}
+	for _, c := range p.Conflicts {
+		fmt.Fprintf(&b, "conflict %s\n", c)
+	}
+	for _, u := range p.Undeclared {
+		fmt.Fprintf(&b, "undeclared property %s (%s)\n", u.Name, formatDataType(u.DataType, u.Transient))
+	}
+	if b.Len() == 0 {
+		fmt.Fprintf(&b, "table %s is up to date\n", p.Table)
+	}
+	return b.String()
+}
+
+// Formats the change as a single line.
+func (c *SchemaChange) String() string {
+	switch c.Action {
+	case CreatePropertyAction:
+		return fmt.Sprintf("%s %s (%s)", c.Action, c.Property.Name, formatDataType(c.Property.DataType, c.Property.Transient))
+	case RenamePropertyAction:
+		return fmt.Sprintf("%s %s -> %s", c.Action, c.From, c.Property.Name)
+	}
+	return c.Action
+}
+
+// Formats the conflict as a single line.
+func (c *SchemaConflict) String() string {
+	return fmt.Sprintf("property %s: declared %s, server has %s (%s)", c.Name, formatDataType(c.Declared.DataType, c.Declared.Transient), c.Actual.Name, formatDataType(c.Actual.DataType, c.Actual.Transient))
+}
+
+// The error message.
+func (e *SchemaConflictError) Error() string {
+	lines := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		lines = append(lines, c.String())
+	}
+	return fmt.Sprintf("sky.Schema: %d conflicts: %s", len(e.Conflicts), strings.Join(lines, "; "))
+}
+
+func formatDataType(dataType string, transient bool) string {
+	if transient {
+		return dataType + ", transient"
+	}
+	return dataType
+}
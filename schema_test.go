@@ -0,0 +1,100 @@
+package sky
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Ensure that a schema creates missing properties and renames old ones.
+func TestSchemaSync(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		table.CreateProperty(NewProperty("sex", false, Factor))
+		table.CreateProperty(NewProperty("legacy", false, String))
+
+		s := NewSchema(testTableName)
+		s.Property("gender", false, Factor).PreviousNames = []string{"sex"}
+		s.Property("price", false, Float)
+		s.Property("note", true, String)
+
+		// A dry run prints the plan without applying it.
+		var buf bytes.Buffer
+		plan, err := s.Sync(client, &SyncOptions{DryRun: true, Writer: &buf})
+		if err != nil || len(plan.Changes) != 3 || len(plan.Undeclared) != 1 {
+			t.Fatalf("Invalid plan: %v (%v)", plan, err)
+		}
+		if !strings.Contains(buf.String(), "rename property sex -> gender\n") || !strings.Contains(buf.String(), "create property note (string, transient)\n") {
+			t.Fatalf("Invalid printed plan: %s", buf.String())
+		}
+		if p, _ := table.GetProperty("gender"); p != nil {
+			t.Fatalf("Dry run applied changes")
+		}
+
+		// Apply the plan.
+		if _, err := s.Sync(client, nil); err != nil {
+			t.Fatalf("Unable to sync schema: %v", err)
+		}
+		properties, _ := table.GetProperties()
+		if len(properties) != 4 {
+			t.Fatalf("Expected 4 properties: %v", properties)
+		}
+		if p, err := table.GetProperty("gender"); err != nil || p.DataType != Factor {
+			t.Fatalf("Property not renamed: %v (%v)", p, err)
+		}
+
+		// A second sync is a no-op.
+		if plan, err := s.Sync(client, nil); err != nil || !plan.Empty() {
+			t.Fatalf("Expected empty plan: %v (%v)", plan, err)
+		}
+	})
+}
+
+// Ensure that conflicting data types are reported and nothing is applied.
+func TestSchemaSyncConflict(t *testing.T) {
+	run(t, func(client Client, table Table) {
+		table.CreateProperty(NewProperty("price", false, Integer))
+
+		s := NewSchema(testTableName)
+		s.Property("price", false, Float)
+		s.Property("name", false, String)
+
+		plan, err := s.Sync(client, nil)
+		var conflictErr *SchemaConflictError
+		if !errors.As(err, &conflictErr) || len(conflictErr.Conflicts) != 1 || conflictErr.Conflicts[0].Name != "price" {
+			t.Fatalf("Expected conflict: %v (%v)", plan, err)
+		}
+		if p, _ := table.GetProperty("name"); p != nil {
+			t.Fatalf("Conflicting sync applied changes")
+		}
+
+		// A property under both its current and previous name isn't renamed.
+		table.CreateProperty(NewProperty("sex", false, Factor))
+		table.CreateProperty(NewProperty("gender", false, Factor))
+		s = NewSchema(testTableName)
+		s.Property("gender", false, Factor).PreviousNames = []string{"sex"}
+		if plan, err := s.Plan(client); err != nil || len(plan.Conflicts) != 1 || len(plan.Changes) != 0 {
+			t.Fatalf("Expected a single conflict: %v (%v)", plan, err)
+		}
+	})
+}
+
+// Ensure that a schema creates its table when missing.
+func TestSchemaSyncCreateTable(t *testing.T) {
+	run(t, func(client Client, _ Table) {
+		s := NewSchema("sky-go-schema")
+		s.Property("action", false, Factor)
+		defer client.DeleteTable(NewTable(s.Table, nil))
+
+		if _, err := s.Sync(client, nil); err != nil {
+			t.Fatalf("Unable to sync schema: %v", err)
+		}
+		table, err := client.GetTable(s.Table)
+		if err != nil {
+			t.Fatalf("Table not created: %v", err)
+		}
+		if p, err := table.GetProperty("action"); err != nil || p.DataType != Factor {
+			t.Fatalf("Property not created: %v (%v)", p, err)
+		}
+	})
+}
@@ -4,17 +4,22 @@ import (
 	"fmt"
 	"os"
 	"testing"
+
+	"github.com/snormore/gosky/skytest"
 )
 
 const (
 	testTableName = "sky-go-integration"
 )
 
-// Setup the test environment.
+// Setup the test environment. Tests run against a local Sky server if one is
+// available, otherwise against an in-memory fake.
 func run(t *testing.T, f func(Client, Table)) {
 	client := NewClientEx("localhost", 8589)
 	if !client.Ping() {
-		t.Fatalf("Server is not running")
+		server := skytest.NewServer()
+		defer server.Close()
+		client = NewClientEx(server.Host(), server.Port())
 	}
 	client.DeleteTable(NewTable(testTableName, nil))
 
@@ -0,0 +1,317 @@
+// Package skyconfig builds Sky clients from environment variables and an
+// optional config file so that every program connects the same way.
+//
+// The environment variables are:
+//
+//	SKY_URL      The server URL, such as https://sky.internal:8585/prefix.
+//	SKY_TIMEOUT  The time limit for each request, such as 30s.
+//	SKY_TOKEN    A bearer token sent with every request and stream.
+//	SKY_CA_FILE  A PEM file of certificate authorities to trust for HTTPS.
+//	SKY_CONFIG   The path of a config file.
+//	SKY_PROFILE  The profile to use from the config file.
+//
+// The config file is YAML, or JSON which is a subset of YAML, and holds
+// named profiles with the same settings:
+//
+//	default: staging
+//	profiles:
+//	  staging:
+//	    url: https://sky.staging:8585
+//	    timeout: 10s
+//	    token: secret
+//	    ca_file: /etc/ssl/sky-ca.pem
+//
+// Environment variables override the settings of the selected profile.
+package skyconfig
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snormore/gosky"
+	"gopkg.in/yaml.v3"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// The name of the profile used when none is selected.
+const DefaultProfile = "default"
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// Config holds the settings needed to connect to a server.
+type Config struct {
+	URL     string
+	Timeout time.Duration
+	Token   string
+	CAFile  string
+}
+
+// A Loader reads configuration from a config file and the environment.
+type Loader struct {
+	// The path of the config file. Defaults to SKY_CONFIG. No file is read
+	// if both are blank.
+	Path string
+
+	// The profile to use. Defaults to SKY_PROFILE, then the file's default
+	// profile and then DefaultProfile.
+	Profile string
+
+	// Settings which take precedence over the file and the environment,
+	// such as command-line flags. Blank settings are ignored.
+	Override Config
+}
+
+// A ValidationError lists every problem found while loading a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+// The format of the config file.
+type file struct {
+	Default  string              `yaml:"default"`
+	Profiles map[string]*profile `yaml:"profiles"`
+}
+
+type profile struct {
+	URL     string `yaml:"url"`
+	Timeout string `yaml:"timeout"`
+	Token   string `yaml:"token"`
+	CAFile  string `yaml:"ca_file"`
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+//--------------------------------------
+// Loader
+//--------------------------------------
+
+// Loads and validates the configuration.
+func (l *Loader) Load() (*Config, error) {
+	var problems []string
+	p := &profile{}
+
+	// Read the selected profile from the config file.
+	path := l.Path
+	if path == "" {
+		path = os.Getenv("SKY_CONFIG")
+	}
+	profileName := l.Profile
+	if profileName == "" {
+		profileName = os.Getenv("SKY_PROFILE")
+	}
+	if path != "" {
+		f, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		explicit := profileName != ""
+		if profileName == "" {
+			profileName = f.Default
+		}
+		if profileName == "" {
+			profileName = DefaultProfile
+		}
+		if fp := f.Profiles[profileName]; fp != nil {
+			p = fp
+		} else if explicit || f.Default != "" {
+			problems = append(problems, fmt.Sprintf("%s: profile %q not found (have %s)", path, profileName, f.profileNames()))
+		}
+	} else if profileName != "" {
+		problems = append(problems, fmt.Sprintf("profile %q selected but no config file given (set SKY_CONFIG)", profileName))
+	}
+	source := "config"
+	if path != "" {
+		source = fmt.Sprintf("%s: profile %q", path, profileName)
+	}
+
+	// Environment variables override the profile.
+	sources := map[string]string{"url": source, "timeout": source, "ca_file": source}
+	for _, env := range []struct {
+		name  string
+		field string
+		value *string
+	}{
+		{"SKY_URL", "url", &p.URL},
+		{"SKY_TIMEOUT", "timeout", &p.Timeout},
+		{"SKY_TOKEN", "token", &p.Token},
+		{"SKY_CA_FILE", "ca_file", &p.CAFile},
+	} {
+		if v := os.Getenv(env.name); v != "" {
+			*env.value = v
+			sources[env.field] = env.name
+		}
+	}
+
+	c := &Config{URL: p.URL, Token: p.Token, CAFile: p.CAFile}
+	if p.Timeout != "" && l.Override.Timeout == 0 {
+		timeout, err := time.ParseDuration(p.Timeout)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: timeout: invalid duration %q (use a value such as 30s)", sources["timeout"], p.Timeout))
+		}
+		c.Timeout = timeout
+	}
+	l.override(c, sources)
+	if err := c.validate(sources, problems); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// override applies the loader's overrides to a configuration.
+func (l *Loader) override(c *Config, sources map[string]string) {
+	if l.Override.URL != "" {
+		c.URL, sources["url"] = l.Override.URL, "override"
+	}
+	if l.Override.Timeout != 0 {
+		c.Timeout, sources["timeout"] = l.Override.Timeout, "override"
+	}
+	if l.Override.Token != "" {
+		c.Token = l.Override.Token
+	}
+	if l.Override.CAFile != "" {
+		c.CAFile, sources["ca_file"] = l.Override.CAFile, "override"
+	}
+}
+
+//--------------------------------------
+// Config
+//--------------------------------------
+
+// Validates the configuration.
+func (c *Config) Validate() error {
+	return c.validate(map[string]string{"url": "config", "timeout": "config", "ca_file": "config"}, nil)
+}
+
+// validate checks every setting and returns a *ValidationError with any
+// problems. Problems are prefixed with where each setting came from.
+func (c *Config) validate(sources map[string]string, problems []string) error {
+	if c.URL == "" {
+		problems = append(problems, "url required (set SKY_URL or url in a config file profile)")
+	} else if _, err := sky.NewClientFromURL(c.URL); err != nil {
+		problems = append(problems, fmt.Sprintf("%s: url: %v", sources["url"], err))
+	}
+	if c.Timeout < 0 {
+		problems = append(problems, fmt.Sprintf("%s: timeout: must not be negative: %v", sources["timeout"], c.Timeout))
+	}
+	if c.CAFile != "" {
+		if _, err := os.Stat(c.CAFile); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: ca_file: %v", sources["ca_file"], err))
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Creates a client from the configuration. Options are applied after the
+// configuration's settings.
+func (c *Config) NewClient(options ...sky.ClientOption) (sky.Client, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	var settings []sky.ClientOption
+	if c.Timeout > 0 {
+		settings = append(settings, sky.WithTimeout(c.Timeout))
+	}
+	if c.Token != "" {
+		settings = append(settings, sky.WithAuthenticator(sky.BearerToken(c.Token)))
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("skyconfig: ca_file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("skyconfig: ca_file: no certificates found in %s", c.CAFile)
+		}
+		settings = append(settings, sky.WithTLSConfig(&tls.Config{RootCAs: pool}))
+	}
+	return sky.NewClientFromURL(c.URL, append(settings, options...)...)
+}
+
+//--------------------------------------
+// Validation Error
+//--------------------------------------
+
+// The error message.
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return "skyconfig: invalid configuration: " + e.Problems[0]
+	}
+	return fmt.Sprintf("skyconfig: invalid configuration:\n  %s", strings.Join(e.Problems, "\n  "))
+}
+
+//--------------------------------------
+// File
+//--------------------------------------
+
+func (f *file) profileNames() string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// Load loads the configuration from SKY_CONFIG, SKY_PROFILE and the other
+// environment variables.
+func Load() (*Config, error) {
+	return (&Loader{}).Load()
+}
+
+// NewClient creates a client from the configuration in the environment.
+func NewClient(options ...sky.ClientOption) (sky.Client, error) {
+	c, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return c.NewClient(options...)
+}
+
+// readFile parses a YAML or JSON config file.
+func readFile(path string) (*file, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("skyconfig: %v", err)
+	}
+	f := &file{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(f); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("skyconfig: %s: %v", path, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,116 @@
+package skyconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+const testFile = `
+default: staging
+profiles:
+  staging:
+    url: https://sky.staging:8585/prefix
+    timeout: 10s
+    token: staging-token
+  local:
+    url: http://localhost
+`
+
+// writeFile writes a config file to a temporary directory.
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Unable to write config file: %v", err)
+	}
+	return path
+}
+
+// Ensure that a configuration is loaded from the environment.
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("SKY_URL", "http://sky.internal:9000")
+	t.Setenv("SKY_TIMEOUT", "5s")
+	t.Setenv("SKY_TOKEN", "secret")
+	c, err := Load()
+	if err != nil || c.URL != "http://sky.internal:9000" || c.Timeout != 5*time.Second || c.Token != "secret" {
+		t.Fatalf("Unexpected config: %v (%v)", c, err)
+	}
+}
+
+// Ensure that profiles are loaded from YAML and JSON files and that the
+// environment overrides them.
+func TestLoadFile(t *testing.T) {
+	path := writeFile(t, "config.yaml", testFile)
+
+	c, err := (&Loader{Path: path}).Load()
+	if err != nil || c.URL != "https://sky.staging:8585/prefix" || c.Timeout != 10*time.Second || c.Token != "staging-token" {
+		t.Fatalf("Unexpected default profile: %v (%v)", c, err)
+	}
+
+	jsonPath := writeFile(t, "config.json", `{"profiles": {"default": {"url": "http://json:8585", "timeout": "1m"}}}`)
+	c, err = (&Loader{Path: jsonPath}).Load()
+	if err != nil || c.URL != "http://json:8585" || c.Timeout != time.Minute {
+		t.Fatalf("Unexpected JSON profile: %v (%v)", c, err)
+	}
+
+	t.Setenv("SKY_CONFIG", path)
+	t.Setenv("SKY_PROFILE", "local")
+	t.Setenv("SKY_TOKEN", "override")
+	c, err = Load()
+	if err != nil || c.URL != "http://localhost" || c.Timeout != 0 || c.Token != "override" {
+		t.Fatalf("Unexpected local profile: %v (%v)", c, err)
+	}
+
+	c, err = (&Loader{Override: Config{URL: "http://flag", Timeout: time.Second}}).Load()
+	if err != nil || c.URL != "http://flag" || c.Timeout != time.Second || c.Token != "override" {
+		t.Fatalf("Unexpected overridden profile: %v (%v)", c, err)
+	}
+}
+
+// Ensure that every problem is reported along with its source.
+func TestLoadValidation(t *testing.T) {
+	path := writeFile(t, "config.yaml", testFile)
+
+	if _, err := (&Loader{}).Load(); err == nil || !strings.Contains(err.Error(), "url required") {
+		t.Fatalf("Expected missing url error: %v", err)
+	}
+	if _, err := (&Loader{Path: path, Profile: "prod"}).Load(); err == nil || !strings.Contains(err.Error(), `profile "prod" not found (have "local", "staging")`) {
+		t.Fatalf("Expected missing profile error: %v", err)
+	}
+
+	t.Setenv("SKY_URL", "ftp://sky")
+	t.Setenv("SKY_TIMEOUT", "5x")
+	t.Setenv("SKY_CA_FILE", filepath.Join(t.TempDir(), "missing.pem"))
+	_, err := (&Loader{Path: path}).Load()
+	e, ok := err.(*ValidationError)
+	if !ok || len(e.Problems) != 3 {
+		t.Fatalf("Expected three problems: %v", err)
+	}
+	for i, prefix := range []string{`SKY_TIMEOUT: timeout: invalid duration "5x"`, "SKY_URL: url:", "SKY_CA_FILE: ca_file:"} {
+		if !strings.HasPrefix(e.Problems[i], prefix) {
+			t.Fatalf("Unexpected problem #%d: %v", i, e.Problems[i])
+		}
+	}
+
+	path = writeFile(t, "bad.yaml", "profiles:\n  default:\n    host: localhost\n")
+	if _, err := (&Loader{Path: path}).Load(); err == nil || !strings.Contains(err.Error(), "field host not found") {
+		t.Fatalf("Expected unknown field error: %v", err)
+	}
+}
+
+// Ensure that a client can be created and connect from the configuration.
+func TestNewClient(t *testing.T) {
+	server := skytest.NewServer()
+	defer server.Close()
+
+	t.Setenv("SKY_URL", server.URL)
+	t.Setenv("SKY_TIMEOUT", "5s")
+	client, err := NewClient()
+	if err != nil || !client.Ping() {
+		t.Fatalf("Unable to connect: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Package skyotel instruments the Sky client with OpenTelemetry metrics and
+// tracing.
+//
+// Requests are counted in sky.client.requests with method, endpoint and
+// error class attributes, with latencies in the sky.client.request.duration
+// histogram. Stream counters are sky.client.stream.events,
+// sky.client.stream.bytes, sky.client.stream.chunks,
+// sky.client.stream.reconnects and sky.client.stream.close_failures with an
+// endpoint attribute.
+//
+//	collector, err := skyotel.NewCollector(otel.Meter("sky"))
+//	client := sky.NewClient("localhost", sky.WithMetrics(collector))
+//
+// The tracer creates a client span for each request and for stream
+// reconnects, flushes and closes, and injects the trace context into the
+// request headers so that server work can be linked to its caller.
+//
+//	client := sky.NewClient("localhost", sky.WithTracer(skyotel.NewTracer(nil, nil)))
+package skyotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/snormore/gosky"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// Collector is a sky.Collector that records to OpenTelemetry instruments.
+type Collector struct {
+	requests metric.Int64Counter
+	latency  metric.Float64Histogram
+	streams  map[string]metric.Int64Counter
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// NewCollector creates the client instruments from a meter.
+func NewCollector(meter metric.Meter) (*Collector, error) {
+	c := &Collector{streams: map[string]metric.Int64Counter{}}
+
+	var err error
+	if c.requests, err = meter.Int64Counter("sky.client.requests",
+		metric.WithDescription("Requests sent to the Sky server."),
+		metric.WithUnit("{request}")); err != nil {
+		return nil, err
+	}
+	if c.latency, err = meter.Float64Histogram("sky.client.request.duration",
+		metric.WithDescription("Latency of requests sent to the Sky server."),
+		metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+
+	units := map[string]string{
+		sky.StreamEvents:        "{event}",
+		sky.StreamBytes:         "By",
+		sky.StreamChunks:        "{chunk}",
+		sky.StreamReconnects:    "{reconnect}",
+		sky.StreamCloseFailures: "{failure}",
+	}
+	for counter, unit := range units {
+		if c.streams[counter], err = meter.Int64Counter("sky.client.stream."+counter, metric.WithUnit(unit)); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// Records a request attempt.
+func (c *Collector) ObserveRequest(method string, endpoint string, latency time.Duration, class string) {
+	ctx := context.Background()
+	c.requests.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("method", method),
+		attribute.String("endpoint", endpoint),
+		attribute.String("class", class),
+	))
+	c.latency.Record(ctx, latency.Seconds(), metric.WithAttributes(
+		attribute.String("method", method),
+		attribute.String("endpoint", endpoint),
+	))
+}
+
+// Adds to a stream counter. Unknown counters are ignored.
+func (c *Collector) AddStreamCount(endpoint string, counter string, n int) {
+	if v := c.streams[counter]; v != nil {
+		v.Add(context.Background(), int64(n), metric.WithAttributes(attribute.String("endpoint", endpoint)))
+	}
+}
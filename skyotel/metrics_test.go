@@ -0,0 +1,66 @@
+package skyotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky"
+	"github.com/snormore/gosky/skytest"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// Ensure that requests and streams are recorded.
+func TestCollector(t *testing.T) {
+	server := skytest.NewServer()
+	defer server.Close()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	c, err := NewCollector(provider.Meter("sky"))
+	if err != nil {
+		t.Fatalf("Unable to create collector: %v", err)
+	}
+	client := sky.NewClientEx(server.Host(), server.Port(), sky.WithMetrics(c))
+	table := sky.NewTable("metrics", nil)
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to create table: %v", err)
+	}
+	stream, err := table.Stream()
+	if err != nil {
+		t.Fatalf("Failed to create event stream: (%v)", err)
+	}
+	for i := 0; i < 3; i++ {
+		stream.AddEvent("xyz", sky.NewEvent(time.Now().Add(time.Duration(i)*time.Hour), nil))
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Closing stream failed: (%v)", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Unable to collect: %v", err)
+	}
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	requests := metrics["sky.client.requests"].(metricdata.Sum[int64])
+	if len(requests.DataPoints) != 1 || requests.DataPoints[0].Value != 1 {
+		t.Fatalf("Unexpected requests: %+v", requests.DataPoints)
+	}
+	if v, _ := requests.DataPoints[0].Attributes.Value(attribute.Key("endpoint")); v.AsString() != "/tables" {
+		t.Fatalf("Unexpected endpoint: %v", v.AsString())
+	}
+	if _, ok := metrics["sky.client.request.duration"].(metricdata.Histogram[float64]); !ok {
+		t.Fatalf("Expected latency histogram: %v", metrics)
+	}
+	if events := metrics["sky.client.stream.events"].(metricdata.Sum[int64]); events.DataPoints[0].Value != 3 {
+		t.Fatalf("Unexpected events: %+v", events.DataPoints)
+	}
+}
@@ -0,0 +1,116 @@
+package skyotel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/snormore/gosky"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// The instrumentation scope name of the client's spans.
+const instrumentationName = "github.com/snormore/gosky"
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// Tracer is a sky.Tracer that creates OpenTelemetry spans and injects trace
+// context headers into requests and streams.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// NewTracer creates a tracer from a tracer provider and propagator. Nil
+// arguments use the global provider and propagator.
+//
+//	client := sky.NewClient("localhost", sky.WithTracer(skyotel.NewTracer(nil, nil)))
+func NewTracer(provider trace.TracerProvider, propagator propagation.TextMapPropagator) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	return &Tracer{
+		tracer:     provider.Tracer(instrumentationName, trace.WithInstrumentationVersion(sky.Version)),
+		propagator: propagator,
+	}
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// Starts a client span. Failed operations are marked as errors along with
+// the server's status code, if there is one.
+func (t *Tracer) Start(ctx context.Context, name string, attrs map[string]interface{}) (context.Context, func(error)) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes(attrs)...))
+	return ctx, func(err error) {
+		var e *sky.Error
+		if errors.As(err, &e) && e.StatusCode != 0 {
+			span.SetAttributes(attribute.Int("http.response.status_code", e.StatusCode))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// Injects the trace context headers for the span in ctx.
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// attributes converts span attributes to OpenTelemetry attributes.
+func attributes(attrs map[string]interface{}) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		switch v := v.(type) {
+		case string:
+			kvs = append(kvs, attribute.String(k, v))
+		case int:
+			kvs = append(kvs, attribute.Int(k, v))
+		case int64:
+			kvs = append(kvs, attribute.Int64(k, v))
+		case float64:
+			kvs = append(kvs, attribute.Float64(k, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(k, v))
+		default:
+			kvs = append(kvs, attribute.String(k, fmt.Sprint(v)))
+		}
+	}
+	return kvs
+}
@@ -0,0 +1,86 @@
+package skyotel
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky"
+	"github.com/snormore/gosky/skytest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Ensure that requests and streams are traced and carry the trace context
+// to the server.
+func TestTracer(t *testing.T) {
+	var mutex sync.Mutex
+	headers := map[string]string{}
+	server := skytest.NewUnstartedServer()
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		headers[r.Method+" "+r.URL.Path] = r.Header.Get("Traceparent")
+		mutex.Unlock()
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := NewTracer(provider, propagation.TraceContext{})
+	client := sky.NewClientEx(server.Host(), server.Port(), sky.WithTracer(tracer))
+	table := sky.NewTable("traced", nil)
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to create table: %v", err)
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "upstream")
+	traceId := parent.SpanContext().TraceID().String()
+	query := map[string]interface{}{"steps": []interface{}{map[string]interface{}{"type": "selection", "fields": []interface{}{map[string]interface{}{"name": "count", "expression": "count()"}}}}}
+	if _, err := table.RawQueryContext(ctx, query); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	stream, err := table.StreamContext(ctx)
+	if err != nil {
+		t.Fatalf("Failed to create event stream: (%v)", err)
+	}
+	stream.AddEvent("xyz", sky.NewEvent(time.Now(), nil))
+	stream.FlushContext(ctx)
+	if err := stream.CloseContext(ctx); err != nil {
+		t.Fatalf("Closing stream failed: (%v)", err)
+	}
+	parent.End()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, key := range []string{"POST /tables/traced/query", "PATCH /tables/traced/events"} {
+		if !strings.Contains(headers[key], traceId) {
+			t.Fatalf("Expected trace context for %s: %q", key, headers[key])
+		}
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	for _, name := range []string{"sky POST /tables/:table/query", "sky stream reconnect", "sky stream flush", "sky stream close"} {
+		span := spans[name]
+		if span == nil || span.SpanContext().TraceID().String() != traceId {
+			t.Fatalf("Expected span %q in trace: %v", name, span)
+		}
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range spans["sky POST /tables/:table/query"].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["sky.table"].AsString() != "traced" || attrs["sky.query.size"].AsInt64() == 0 {
+		t.Fatalf("Unexpected query attributes: %v", attrs)
+	}
+}
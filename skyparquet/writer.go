@@ -0,0 +1,168 @@
+// Package skyparquet writes exported Sky events as Parquet files.
+//
+// Each event is a row with a required "id" string column, a required
+// "timestamp" column in nanoseconds since the epoch and an optional column
+// for each property: int64 for integers, double for floats, boolean for
+// booleans and string for strings and factors.
+package skyparquet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/snormore/gosky"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// The number of rows buffered between writes to the Parquet writer.
+const batchSize = 1024
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// Writer is a sky.EventWriter for Parquet files.
+type Writer struct {
+	w          io.Writer
+	writer     *parquet.Writer
+	properties []*sky.Property
+	columns    map[string]int
+	rows       []parquet.Row
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// NewWriter returns an event writer that writes Parquet to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// Builds the Parquet schema from the table's properties.
+func (w *Writer) WriteHeader(properties []*sky.Property) error {
+	group := parquet.Group{
+		"id":        parquet.String(),
+		"timestamp": parquet.Timestamp(parquet.Nanosecond),
+	}
+	for _, p := range properties {
+		if _, ok := group[p.Name]; ok {
+			return fmt.Errorf("skyparquet: Property conflicts with a built-in column: %s", p.Name)
+		}
+		switch p.DataType {
+		case sky.Integer:
+			group[p.Name] = parquet.Optional(parquet.Int(64))
+		case sky.Float:
+			group[p.Name] = parquet.Optional(parquet.Leaf(parquet.DoubleType))
+		case sky.Boolean:
+			group[p.Name] = parquet.Optional(parquet.Leaf(parquet.BooleanType))
+		default:
+			group[p.Name] = parquet.Optional(parquet.String())
+		}
+	}
+	schema := parquet.NewSchema("event", group)
+
+	w.properties = properties
+	w.columns = make(map[string]int)
+	for i, path := range schema.Columns() {
+		w.columns[strings.Join(path, ".")] = i
+	}
+	w.writer = parquet.NewWriter(w.w, schema)
+	return nil
+}
+
+// Buffers a single event as a row.
+func (w *Writer) WriteEvent(objectId string, event *sky.Event) error {
+	if w.writer == nil {
+		return errors.New("skyparquet: WriteHeader must be called before WriteEvent")
+	}
+	row := make(parquet.Row, len(w.columns))
+	row[w.columns["id"]] = parquet.ByteArrayValue([]byte(objectId)).Level(0, 0, w.columns["id"])
+	row[w.columns["timestamp"]] = parquet.Int64Value(event.Timestamp.UnixNano()).Level(0, 0, w.columns["timestamp"])
+	for _, p := range w.properties {
+		column := w.columns[p.Name]
+		value, err := parquetValue(event.Data[p.Name], p.DataType)
+		if err != nil {
+			return fmt.Errorf("skyparquet: %s: %v", p.Name, err)
+		}
+		if value.IsNull() {
+			row[column] = value.Level(0, 0, column)
+		} else {
+			row[column] = value.Level(0, 1, column)
+		}
+	}
+
+	if w.rows = append(w.rows, row); len(w.rows) >= batchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Writes the remaining rows and the Parquet footer. The underlying writer
+// is not closed.
+func (w *Writer) Close() error {
+	if w.writer == nil {
+		return nil
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.writer.Close()
+}
+
+func (w *Writer) flush() error {
+	_, err := w.writer.WriteRows(w.rows)
+	w.rows = w.rows[:0]
+	return err
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// parquetValue converts an event value to a Parquet value for a data type.
+func parquetValue(value interface{}, dataType string) (parquet.Value, error) {
+	if value == nil {
+		return parquet.NullValue(), nil
+	}
+	v := sky.ExportValue(value, dataType)
+	switch dataType {
+	case sky.Integer:
+		if i, ok := v.(int64); ok {
+			return parquet.Int64Value(i), nil
+		}
+	case sky.Float:
+		if f, ok := v.(float64); ok {
+			return parquet.DoubleValue(f), nil
+		}
+	case sky.Boolean:
+		if b, ok := v.(bool); ok {
+			return parquet.BooleanValue(b), nil
+		}
+	default:
+		if str, ok := v.(string); ok {
+			return parquet.ByteArrayValue([]byte(str)), nil
+		}
+	}
+	return parquet.Value{}, fmt.Errorf("invalid %s: %v", dataType, value)
+}
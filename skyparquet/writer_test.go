@@ -0,0 +1,58 @@
+package skyparquet
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/snormore/gosky"
+	"github.com/snormore/gosky/skytest"
+)
+
+type row struct {
+	Id        string    `parquet:"id"`
+	Timestamp time.Time `parquet:"timestamp,timestamp(nanosecond)"`
+	Active    *bool     `parquet:"active,optional"`
+	Age       *int64    `parquet:"age,optional"`
+	Name      *string   `parquet:"name,optional"`
+	Score     *float64  `parquet:"score,optional"`
+}
+
+// Ensure that a table's events are exported as typed Parquet columns.
+func TestExport(t *testing.T) {
+	server := skytest.NewServer()
+	defer server.Close()
+	client := sky.NewClientEx(server.Host(), server.Port())
+	table := sky.NewTable("users", nil)
+	client.CreateTable(table)
+	table.CreateProperty(sky.NewProperty("name", false, sky.String))
+	table.CreateProperty(sky.NewProperty("age", false, sky.Integer))
+	table.CreateProperty(sky.NewProperty("score", false, sky.Float))
+	table.CreateProperty(sky.NewProperty("active", true, sky.Boolean))
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	table.AddEvent("u1", sky.NewEvent(t0, map[string]interface{}{"name": "bob", "age": 41, "score": 1.5}), sky.Replace)
+	table.AddEvent("u1", sky.NewEvent(t0.Add(time.Hour), map[string]interface{}{"active": true}), sky.Replace)
+	table.AddEvent("u2", sky.NewEvent(t0, map[string]interface{}{"age": 7}), sky.Replace)
+
+	var buf bytes.Buffer
+	count, err := sky.Export(table, NewWriter(&buf), nil)
+	if err != nil || count != 3 {
+		t.Fatalf("Unable to export: %d (%v)", count, err)
+	}
+
+	rows, err := parquet.Read[row](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("Unable to read Parquet: %d (%v)", len(rows), err)
+	}
+	if r := rows[0]; r.Id != "u1" || !r.Timestamp.Equal(t0) || *r.Name != "bob" || *r.Age != 41 || *r.Score != 1.5 || r.Active != nil {
+		t.Fatalf("Unexpected row #0: %+v", r)
+	}
+	if r := rows[1]; r.Id != "u1" || !r.Timestamp.Equal(t0.Add(time.Hour)) || !*r.Active || r.Name != nil || r.Age != nil {
+		t.Fatalf("Unexpected row #1: %+v", r)
+	}
+	if r := rows[2]; r.Id != "u2" || *r.Age != 7 {
+		t.Fatalf("Unexpected row #2: %+v", r)
+	}
+}
@@ -0,0 +1,127 @@
+// Package skyprometheus records Sky client metrics with Prometheus.
+//
+// Requests are counted in sky_client_requests_total by method, endpoint and
+// error class, with latencies in sky_client_request_duration_seconds. Stream
+// counters are sky_client_stream_events_total, sky_client_stream_bytes_total,
+// sky_client_stream_chunks_total, sky_client_stream_reconnects_total and
+// sky_client_stream_close_failures_total, labeled by endpoint.
+//
+//	collector := skyprometheus.NewCollector(prometheus.DefaultRegisterer)
+//	client := sky.NewClient("localhost", sky.WithMetrics(collector))
+package skyprometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/snormore/gosky"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+const (
+	namespace = "sky"
+	subsystem = "client"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// Collector is a sky.Collector that records to Prometheus metrics.
+type Collector struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	streams  map[string]*prometheus.CounterVec
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// NewCollector creates the client metrics and registers them with reg. A nil
+// registerer leaves them unregistered.
+func NewCollector(reg prometheus.Registerer) *Collector {
+	c := &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_total",
+			Help:      "Requests sent to the Sky server by method, endpoint and error class.",
+		}, []string{"method", "endpoint", "class"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests sent to the Sky server.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "endpoint"}),
+		streams: map[string]*prometheus.CounterVec{},
+	}
+
+	help := map[string]string{
+		sky.StreamEvents:        "Events encoded into streams.",
+		sky.StreamBytes:         "Bytes written to streams.",
+		sky.StreamChunks:        "Chunks written to streams.",
+		sky.StreamReconnects:    "Stream reconnection attempts.",
+		sky.StreamCloseFailures: "Stream requests that failed to close.",
+	}
+	for counter, text := range help {
+		c.streams[counter] = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "stream_" + counter + "_total",
+			Help:      text,
+		}, []string{"endpoint"})
+	}
+
+	if reg != nil {
+		reg.MustRegister(c)
+	}
+	return c
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// Records a request attempt.
+func (c *Collector) ObserveRequest(method string, endpoint string, latency time.Duration, class string) {
+	c.requests.WithLabelValues(method, endpoint, class).Inc()
+	c.latency.WithLabelValues(method, endpoint).Observe(latency.Seconds())
+}
+
+// Adds to a stream counter. Unknown counters are ignored.
+func (c *Collector) AddStreamCount(endpoint string, counter string, n int) {
+	if v := c.streams[counter]; v != nil {
+		v.WithLabelValues(endpoint).Add(float64(n))
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.latency.Describe(ch)
+	for _, v := range c.streams {
+		v.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.latency.Collect(ch)
+	for _, v := range c.streams {
+		v.Collect(ch)
+	}
+}
@@ -0,0 +1,50 @@
+package skyprometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/snormore/gosky"
+	"github.com/snormore/gosky/skytest"
+)
+
+// Ensure that requests and streams are recorded.
+func TestCollector(t *testing.T) {
+	server := skytest.NewServer()
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	c := NewCollector(reg)
+	client := sky.NewClientEx(server.Host(), server.Port(), sky.WithMetrics(c))
+	table := sky.NewTable("metrics", nil)
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to create table: %v", err)
+	}
+	client.GetTable("missing")
+
+	stream, err := table.Stream()
+	if err != nil {
+		t.Fatalf("Failed to create event stream: (%v)", err)
+	}
+	for i := 0; i < 3; i++ {
+		stream.AddEvent("xyz", sky.NewEvent(time.Now().Add(time.Duration(i)*time.Hour), nil))
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Closing stream failed: (%v)", err)
+	}
+
+	if v := testutil.ToFloat64(c.requests.WithLabelValues("POST", "/tables", sky.ErrorClassNone)); v != 1 {
+		t.Fatalf("Unexpected request count: %v", v)
+	}
+	if v := testutil.ToFloat64(c.requests.WithLabelValues("GET", "/tables/:table", sky.ErrorClassNotFound)); v != 1 {
+		t.Fatalf("Unexpected failed request count: %v", v)
+	}
+	if v := testutil.ToFloat64(c.streams[sky.StreamEvents].WithLabelValues("/tables/:table/events")); v != 3 {
+		t.Fatalf("Unexpected event count: %v", v)
+	}
+	if n, err := testutil.GatherAndCount(reg, "sky_client_request_duration_seconds", "sky_client_stream_bytes_total"); err != nil || n != 3 {
+		t.Fatalf("Unexpected metric count: %d (%v)", n, err)
+	}
+}
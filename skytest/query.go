@@ -0,0 +1,402 @@
+package skytest
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// A query is a parsed Sky query. Only a subset of the query language is
+// supported: selection and condition steps, count/sum/min/max aggregations,
+// dimensions, WITHIN step ranges and session idle times.
+type query struct {
+	sessionIdleTime time.Duration
+	steps           []step
+}
+
+type step interface{}
+
+type selection struct {
+	name       string
+	dimensions []string
+	fields     []*field
+}
+
+type field struct {
+	name       string
+	aggregator string
+	property   string
+}
+
+type condition struct {
+	expression expression
+	within     [2]int
+	steps      []step
+}
+
+// An expression is a boolean predicate over a single event.
+type expression func(e *event) bool
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// query executes a raw query against the table.
+func (t *table) query(obj map[string]interface{}) (interface{}, error) {
+	q, err := t.parseQuery(obj)
+	if err != nil {
+		return nil, &statusError{http.StatusBadRequest, err.Error()}
+	}
+
+	results := map[string]interface{}{}
+	for _, events := range t.objects {
+		for _, session := range q.sessions(events) {
+			for i := range session {
+				q.eval(q.steps, session, i, results)
+			}
+		}
+	}
+	return results, nil
+}
+
+// sessions splits an object's events into sessions separated by the idle time.
+func (q *query) sessions(events []*event) [][]*event {
+	if q.sessionIdleTime <= 0 || len(events) == 0 {
+		return [][]*event{events}
+	}
+	sessions, start := [][]*event{}, 0
+	for i := 1; i < len(events); i++ {
+		if events[i].timestamp.Sub(events[i-1].timestamp) >= q.sessionIdleTime {
+			sessions = append(sessions, events[start:i])
+			start = i
+		}
+	}
+	return append(sessions, events[start:])
+}
+
+// eval evaluates a list of steps at a given position within a session.
+func (q *query) eval(steps []step, events []*event, index int, results map[string]interface{}) {
+	for _, s := range steps {
+		switch s := s.(type) {
+		case *selection:
+			s.eval(events[index], results)
+		case *condition:
+			for i := index + s.within[0]; i <= index+s.within[1] && i < len(events); i++ {
+				if i >= 0 && s.expression(events[i]) {
+					q.eval(s.steps, events, i, results)
+					break
+				}
+			}
+		}
+	}
+}
+
+// eval aggregates a single event into the results.
+func (s *selection) eval(e *event, results map[string]interface{}) {
+	target := results
+	if s.name != "" {
+		target = child(target, s.name)
+	}
+	for _, dimension := range s.dimensions {
+		target = child(child(target, dimension), format(e.data[dimension]))
+	}
+
+	for _, f := range s.fields {
+		if f.aggregator == "count" {
+			n, _ := target[f.name].(float64)
+			target[f.name] = n + 1
+			continue
+		}
+
+		v, ok := e.data[f.property].(float64)
+		if !ok {
+			continue
+		}
+		prev, exists := target[f.name].(float64)
+		switch {
+		case !exists:
+			target[f.name] = v
+		case f.aggregator == "sum":
+			target[f.name] = prev + v
+		case f.aggregator == "min":
+			target[f.name] = math.Min(prev, v)
+		case f.aggregator == "max":
+			target[f.name] = math.Max(prev, v)
+		}
+	}
+}
+
+//--------------------------------------
+// Parsing
+//--------------------------------------
+
+var fieldPattern = regexp.MustCompile(`^\s*(count|sum|min|max)\(\s*(\w*)\s*\)\s*$`)
+
+func (t *table) parseQuery(obj map[string]interface{}) (*query, error) {
+	q := &query{}
+	if v, ok := obj["sessionIdleTime"].(float64); ok {
+		q.sessionIdleTime = time.Duration(v) * time.Second
+	}
+	steps, err := t.parseSteps(obj["steps"])
+	if err != nil {
+		return nil, err
+	}
+	q.steps = steps
+	return q, nil
+}
+
+func (t *table) parseSteps(v interface{}) ([]step, error) {
+	items, _ := v.([]interface{})
+	steps := make([]step, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Invalid step: %v", item)
+		}
+		switch obj["type"] {
+		case "selection":
+			s, err := t.parseSelection(obj)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+		case "condition":
+			c, err := t.parseCondition(obj)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, c)
+		default:
+			return nil, fmt.Errorf("Invalid step type: %v", obj["type"])
+		}
+	}
+	return steps, nil
+}
+
+func (t *table) parseSelection(obj map[string]interface{}) (*selection, error) {
+	s := &selection{}
+	s.name, _ = obj["name"].(string)
+
+	dimensions, _ := obj["dimensions"].([]interface{})
+	for _, d := range dimensions {
+		name, _ := d.(string)
+		if t.getProperty(name) == nil {
+			return nil, fmt.Errorf("Invalid dimension: %v", d)
+		}
+		s.dimensions = append(s.dimensions, name)
+	}
+
+	fields, _ := obj["fields"].([]interface{})
+	for _, item := range fields {
+		f, _ := item.(map[string]interface{})
+		name, _ := f["name"].(string)
+		expr, _ := f["expression"].(string)
+		m := fieldPattern.FindStringSubmatch(expr)
+		if name == "" || m == nil {
+			return nil, fmt.Errorf("Invalid field: %v", item)
+		}
+		if m[1] != "count" && t.getProperty(m[2]) == nil {
+			return nil, fmt.Errorf("Invalid field expression: %s", expr)
+		}
+		s.fields = append(s.fields, &field{name: name, aggregator: m[1], property: m[2]})
+	}
+	return s, nil
+}
+
+func (t *table) parseCondition(obj map[string]interface{}) (*condition, error) {
+	c := &condition{}
+
+	str, _ := obj["expression"].(string)
+	expr, err := t.parseExpression(str)
+	if err != nil {
+		return nil, err
+	}
+	c.expression = expr
+
+	if within, ok := obj["within"].([]interface{}); ok {
+		if len(within) != 2 {
+			return nil, fmt.Errorf("Invalid within range: %v", within)
+		}
+		for i := range within {
+			n, _ := within[i].(float64)
+			c.within[i] = int(n)
+		}
+		if units, _ := obj["withinUnits"].(string); units != "" && units != "steps" {
+			return nil, fmt.Errorf("Unsupported within units: %s", units)
+		}
+	}
+
+	steps, err := t.parseSteps(obj["steps"])
+	if err != nil {
+		return nil, err
+	}
+	c.steps = steps
+	return c, nil
+}
+
+// parseExpression parses a condition expression. Supported expressions are
+// "true", "false" and comparisons of a property to a literal joined by "&&"
+// or "||".
+func (t *table) parseExpression(str string) (expression, error) {
+	str = strings.TrimSpace(str)
+	if str == "" || str == "true" {
+		return func(*event) bool { return true }, nil
+	} else if str == "false" {
+		return func(*event) bool { return false }, nil
+	}
+
+	if parts := strings.Split(str, "||"); len(parts) > 1 {
+		exprs, err := t.parseExpressions(parts)
+		if err != nil {
+			return nil, err
+		}
+		return func(e *event) bool {
+			for _, expr := range exprs {
+				if expr(e) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	if parts := strings.Split(str, "&&"); len(parts) > 1 {
+		exprs, err := t.parseExpressions(parts)
+		if err != nil {
+			return nil, err
+		}
+		return func(e *event) bool {
+			for _, expr := range exprs {
+				if !expr(e) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	}
+	return t.parseComparison(str)
+}
+
+func (t *table) parseExpressions(parts []string) ([]expression, error) {
+	exprs := make([]expression, 0, len(parts))
+	for _, part := range parts {
+		expr, err := t.parseExpression(part)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs, nil
+}
+
+var comparisonPattern = regexp.MustCompile(`^(\w+)\s*(==|!=|<=|>=|<|>)\s*(.+)$`)
+
+func (t *table) parseComparison(str string) (expression, error) {
+	m := comparisonPattern.FindStringSubmatch(str)
+	if m == nil {
+		return nil, fmt.Errorf("Invalid expression: %s", str)
+	}
+	name, op, literal := m[1], m[2], strings.TrimSpace(m[3])
+	if t.getProperty(name) == nil {
+		return nil, fmt.Errorf("Invalid expression property: %s", name)
+	}
+
+	var value interface{}
+	if n := len(literal); n >= 2 && (literal[0] == '"' || literal[0] == '\'') && literal[n-1] == literal[0] {
+		value = literal[1 : n-1]
+	} else if literal == "true" || literal == "false" {
+		value = literal == "true"
+	} else if f, err := strconv.ParseFloat(literal, 64); err == nil {
+		value = f
+	} else {
+		return nil, fmt.Errorf("Invalid expression literal: %s", literal)
+	}
+
+	return func(e *event) bool {
+		cmp, ok := compare(e.data[name], value)
+		if !ok {
+			return op == "!="
+		}
+		switch op {
+		case "==":
+			return cmp == 0
+		case "!=":
+			return cmp != 0
+		case "<":
+			return cmp < 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		default:
+			return cmp >= 0
+		}
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// child returns the nested map under a key, creating it if necessary.
+func child(m map[string]interface{}, key string) map[string]interface{} {
+	if c, ok := m[key].(map[string]interface{}); ok {
+		return c
+	}
+	c := map[string]interface{}{}
+	m[key] = c
+	return c
+}
+
+// format converts a dimension value to its string key.
+func format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// compare compares two values of the same type. Returns false if the values
+// are not comparable.
+func compare(a, b interface{}) (int, bool) {
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), true
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			switch {
+			case a < b:
+				return -1, true
+			case a > b:
+				return 1, true
+			}
+			return 0, true
+		}
+	case bool:
+		if b, ok := b.(bool); ok && a == b {
+			return 0, true
+		} else if ok {
+			return 1, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,701 @@
+// Package skytest provides an in-memory Sky server for use in tests.
+//
+// The server implements the subset of the Sky HTTP API used by the client:
+// tables, properties, objects and their events, the chunked PATCH event
+// streams, stats and queries. All data is kept in memory and discarded when
+// the server is closed.
+package skytest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+const (
+	String  = "string"
+	Integer = "integer"
+	Float   = "float"
+	Boolean = "boolean"
+	Factor  = "factor"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// Server is an in-memory Sky server listening on a local port.
+type Server struct {
+	*httptest.Server
+	mutex  sync.Mutex
+	tables map[string]*table
+}
+
+type table struct {
+	name       string
+	properties []*property
+	objects    map[string][]*event
+	nextId     int
+	nextTId    int
+}
+
+type property struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Transient bool   `json:"transient"`
+	DataType  string `json:"dataType"`
+}
+
+type event struct {
+	timestamp time.Time
+	data      map[string]interface{}
+}
+
+// statusError is an error with an associated HTTP status code.
+type statusError struct {
+	status  int
+	message string
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// NewServer starts and returns a new in-memory server. The caller should
+// call Close when finished to shut it down.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a new in-memory server that is not yet
+// listening. The caller can configure the embedded httptest.Server and then
+// call Start or StartTLS.
+func NewUnstartedServer() *Server {
+	s := &Server{tables: make(map[string]*table)}
+	s.Server = httptest.NewUnstartedServer(s)
+	return s
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// Host returns the host name the server is listening on.
+func (s *Server) Host() string {
+	u, _ := url.Parse(s.URL)
+	return u.Hostname()
+}
+
+// Port returns the port the server is listening on.
+func (s *Server) Port() uint {
+	u, _ := url.Parse(s.URL)
+	port, _ := strconv.ParseUint(u.Port(), 10, 32)
+	return uint(port)
+}
+
+// Reset removes all tables from the server.
+func (s *Server) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tables = make(map[string]*table)
+}
+
+//--------------------------------------
+// Routing
+//--------------------------------------
+
+// ServeHTTP routes a request to the appropriate handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for i, segment := range segments {
+		if v, err := url.PathUnescape(segment); err == nil {
+			segments[i] = v
+		}
+	}
+
+	// Event streams are handled on the raw connection.
+	if r.Method == "PATCH" && (r.URL.Path == "/events" || (len(segments) == 3 && segments[0] == "tables" && segments[2] == "events")) {
+		var name string
+		if len(segments) == 3 {
+			name = segments[1]
+		}
+		s.serveStream(w, r, name)
+		return
+	}
+
+	ret, err := s.route(r, segments)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if ret == nil {
+		ret = map[string]interface{}{}
+	}
+	json.NewEncoder(w).Encode(ret)
+}
+
+func (s *Server) route(r *http.Request, segments []string) (interface{}, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case len(segments) == 1 && segments[0] == "ping":
+		return nil, nil
+
+	case len(segments) == 1 && segments[0] == "tables":
+		switch r.Method {
+		case "GET":
+			return s.getTables(), nil
+		case "POST":
+			return s.createTable(r)
+		}
+
+	case len(segments) >= 2 && segments[0] == "tables":
+		t := s.tables[segments[1]]
+		if t == nil {
+			return nil, &statusError{http.StatusNotFound, fmt.Sprintf("Table does not exist: %s", segments[1])}
+		}
+		return s.routeTable(r, t, segments[2:])
+	}
+	return nil, &statusError{http.StatusNotFound, fmt.Sprintf("Not found: %s %s", r.Method, r.URL.Path)}
+}
+
+func (s *Server) routeTable(r *http.Request, t *table, segments []string) (interface{}, error) {
+	switch {
+	case len(segments) == 0:
+		switch r.Method {
+		case "GET":
+			return map[string]interface{}{"name": t.name}, nil
+		case "DELETE":
+			delete(s.tables, t.name)
+			return nil, nil
+		}
+
+	case len(segments) == 1 && segments[0] == "properties":
+		switch r.Method {
+		case "GET":
+			return t.getProperties(), nil
+		case "POST":
+			p := &property{}
+			if err := decode(r, p); err != nil {
+				return nil, err
+			}
+			return t.createProperty(p)
+		}
+
+	case len(segments) == 2 && segments[0] == "properties":
+		p := t.getProperty(segments[1])
+		if p == nil {
+			return nil, &statusError{http.StatusNotFound, fmt.Sprintf("Property does not exist: %s", segments[1])}
+		}
+		switch r.Method {
+		case "GET":
+			return p, nil
+		case "PATCH":
+			tmp := &property{}
+			if err := decode(r, tmp); err != nil {
+				return nil, err
+			}
+			return t.updateProperty(p, tmp.Name)
+		case "DELETE":
+			t.deleteProperty(p)
+			return nil, nil
+		}
+
+	case len(segments) == 1 && segments[0] == "objects":
+		if r.Method == "GET" {
+			ids := make([]string, 0, len(t.objects))
+			for id, events := range t.objects {
+				if len(events) > 0 {
+					ids = append(ids, id)
+				}
+			}
+			sort.Strings(ids)
+			return ids, nil
+		}
+
+	case len(segments) == 2 && segments[0] == "objects":
+		if r.Method == "GET" {
+			events := t.objects[segments[1]]
+			if len(events) == 0 {
+				return nil, &statusError{http.StatusNotFound, "Object not found"}
+			}
+			return map[string]interface{}{
+				"id":    segments[1],
+				"count": len(events),
+				"first": formatTimestamp(events[0].timestamp),
+				"last":  formatTimestamp(events[len(events)-1].timestamp),
+			}, nil
+		}
+
+	case len(segments) == 3 && segments[0] == "objects" && segments[2] == "events":
+		switch r.Method {
+		case "GET":
+			return t.getEvents(segments[1], r.URL.Query())
+		case "DELETE":
+			delete(t.objects, segments[1])
+			return nil, nil
+		}
+
+	case len(segments) == 4 && segments[0] == "objects" && segments[2] == "events":
+		timestamp, err := parseTimestamp(segments[3])
+		if err != nil {
+			return nil, &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid timestamp: %s", segments[3])}
+		}
+		switch r.Method {
+		case "GET":
+			if e := t.getEvent(segments[1], timestamp); e != nil {
+				return e.serialize(), nil
+			}
+			return (&event{timestamp: timestamp, data: map[string]interface{}{}}).serialize(), nil
+		case "PUT", "PATCH":
+			obj := map[string]interface{}{}
+			if err := decode(r, &obj); err != nil {
+				return nil, err
+			}
+			data, _ := obj["data"].(map[string]interface{})
+			return nil, t.insertEvent(segments[1], &event{timestamp: timestamp, data: data}, r.Method == "PATCH")
+		case "DELETE":
+			t.deleteEvent(segments[1], timestamp)
+			return nil, nil
+		}
+
+	case len(segments) == 1 && segments[0] == "stats":
+		if r.Method == "GET" {
+			return map[string]interface{}{"count": t.eventCount()}, nil
+		}
+
+	case len(segments) == 1 && segments[0] == "query":
+		if r.Method == "POST" {
+			q := map[string]interface{}{}
+			if err := decode(r, &q); err != nil {
+				return nil, err
+			}
+			return t.query(q)
+		}
+	}
+	return nil, &statusError{http.StatusNotFound, fmt.Sprintf("Not found: %s %s", r.Method, r.URL.Path)}
+}
+
+//--------------------------------------
+// Tables
+//--------------------------------------
+
+func (s *Server) getTables() []map[string]interface{} {
+	names := make([]string, 0, len(s.tables))
+	for name := range s.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	output := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		output = append(output, map[string]interface{}{"name": name})
+	}
+	return output
+}
+
+func (s *Server) createTable(r *http.Request) (interface{}, error) {
+	obj := map[string]interface{}{}
+	if err := decode(r, &obj); err != nil {
+		return nil, err
+	}
+	name, _ := obj["name"].(string)
+	if name == "" {
+		return nil, &statusError{http.StatusBadRequest, "Table name required"}
+	}
+	if s.tables[name] != nil {
+		return nil, &statusError{http.StatusConflict, fmt.Sprintf("Table already exists: %s", name)}
+	}
+	s.tables[name] = &table{name: name, objects: make(map[string][]*event)}
+	return map[string]interface{}{"name": name}, nil
+}
+
+//--------------------------------------
+// Properties
+//--------------------------------------
+
+func (t *table) getProperty(name string) *property {
+	for _, p := range t.properties {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func (t *table) getProperties() []*property {
+	properties := make([]*property, len(t.properties))
+	copy(properties, t.properties)
+	sort.Sort(propertiesById(properties))
+	return properties
+}
+
+func (t *table) createProperty(p *property) (*property, error) {
+	if p.Name == "" {
+		return nil, &statusError{http.StatusBadRequest, "Property name required"}
+	}
+	if t.getProperty(p.Name) != nil {
+		return nil, &statusError{http.StatusConflict, fmt.Sprintf("Property already exists: %s", p.Name)}
+	}
+	switch p.DataType {
+	case String, Integer, Float, Boolean, Factor:
+	default:
+		return nil, &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid data type: %s", p.DataType)}
+	}
+
+	// Permanent properties have positive ids; transient ones are negative.
+	if p.Transient {
+		t.nextTId--
+		p.Id = t.nextTId
+	} else {
+		t.nextId++
+		p.Id = t.nextId
+	}
+	t.properties = append(t.properties, p)
+	return p, nil
+}
+
+func (t *table) updateProperty(p *property, name string) (*property, error) {
+	if name == "" {
+		return nil, &statusError{http.StatusBadRequest, "Property name required"}
+	}
+	if other := t.getProperty(name); other != nil && other != p {
+		return nil, &statusError{http.StatusConflict, fmt.Sprintf("Property already exists: %s", name)}
+	}
+
+	// Only the name can be changed. Rename the key on existing events too.
+	if p.Name != name {
+		for _, events := range t.objects {
+			for _, e := range events {
+				if v, ok := e.data[p.Name]; ok {
+					delete(e.data, p.Name)
+					e.data[name] = v
+				}
+			}
+		}
+		p.Name = name
+	}
+	return p, nil
+}
+
+func (t *table) deleteProperty(p *property) {
+	for i, other := range t.properties {
+		if other == p {
+			t.properties = append(t.properties[:i], t.properties[i+1:]...)
+			break
+		}
+	}
+	for _, events := range t.objects {
+		for _, e := range events {
+			delete(e.data, p.Name)
+		}
+	}
+}
+
+//--------------------------------------
+// Events
+//--------------------------------------
+
+func (t *table) getEvent(objectId string, timestamp time.Time) *event {
+	for _, e := range t.objects[objectId] {
+		if e.timestamp.Equal(timestamp) {
+			return e
+		}
+	}
+	return nil
+}
+
+// getEvents returns an object's events within the optional "start" and
+// "end" timestamps, up to "limit" events, newest first if "order" is "desc".
+func (t *table) getEvents(objectId string, query url.Values) (interface{}, error) {
+	var start, end time.Time
+	var limit int
+	var err error
+	if v := query.Get("start"); v != "" {
+		if start, err = parseTimestamp(v); err != nil {
+			return nil, &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid start: %s", v)}
+		}
+	}
+	if v := query.Get("end"); v != "" {
+		if end, err = parseTimestamp(v); err != nil {
+			return nil, &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid end: %s", v)}
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			return nil, &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid limit: %s", v)}
+		}
+	}
+
+	events := t.objects[objectId]
+	output := make([]map[string]interface{}, 0, len(events))
+	for i := range events {
+		e := events[i]
+		if query.Get("order") == "desc" {
+			e = events[len(events)-1-i]
+		}
+		if (!start.IsZero() && e.timestamp.Before(start)) || (!end.IsZero() && !e.timestamp.Before(end)) {
+			continue
+		}
+		if limit > 0 && len(output) >= limit {
+			break
+		}
+		output = append(output, e.serialize())
+	}
+	return output, nil
+}
+
+// insertEvent adds an event to an object. If merge is set then the data is
+// merged into any existing event with the same timestamp, otherwise it
+// replaces it.
+func (t *table) insertEvent(objectId string, e *event, merge bool) error {
+	if objectId == "" {
+		return &statusError{http.StatusBadRequest, "Object identifier required"}
+	}
+	data, err := t.normalize(e.data)
+	if err != nil {
+		return err
+	}
+
+	if existing := t.getEvent(objectId, e.timestamp); existing != nil {
+		if !merge {
+			existing.data = map[string]interface{}{}
+		}
+		for k, v := range data {
+			existing.data[k] = v
+		}
+		return nil
+	}
+
+	events := append(t.objects[objectId], &event{timestamp: e.timestamp, data: data})
+	sort.Sort(eventsByTimestamp(events))
+	t.objects[objectId] = events
+	return nil
+}
+
+func (t *table) deleteEvent(objectId string, timestamp time.Time) {
+	events := t.objects[objectId]
+	for i, e := range events {
+		if e.timestamp.Equal(timestamp) {
+			t.objects[objectId] = append(events[:i], events[i+1:]...)
+			break
+		}
+	}
+}
+
+// normalize validates event data against the table's properties and coerces
+// each value to the property's data type.
+func (t *table) normalize(data map[string]interface{}) (map[string]interface{}, error) {
+	output := map[string]interface{}{}
+	for k, v := range data {
+		p := t.getProperty(k)
+		if p == nil {
+			return nil, &statusError{http.StatusBadRequest, fmt.Sprintf("Property not found: %s", k)}
+		}
+		if v == nil {
+			continue
+		}
+		switch p.DataType {
+		case Integer, Float:
+			n, ok := v.(float64)
+			if !ok {
+				return nil, &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid %s value for %s: %v", p.DataType, k, v)}
+			}
+			if p.DataType == Integer {
+				n = float64(int64(n))
+			}
+			v = n
+		case Boolean:
+			if _, ok := v.(bool); !ok {
+				return nil, &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid boolean value for %s: %v", k, v)}
+			}
+		default:
+			if _, ok := v.(string); !ok {
+				return nil, &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid %s value for %s: %v", p.DataType, k, v)}
+			}
+		}
+		output[k] = v
+	}
+	return output, nil
+}
+
+func (t *table) eventCount() int {
+	count := 0
+	for _, events := range t.objects {
+		count += len(events)
+	}
+	return count
+}
+
+func (e *event) serialize() map[string]interface{} {
+	data := map[string]interface{}{}
+	for k, v := range e.data {
+		data[k] = v
+	}
+	return map[string]interface{}{
+		"timestamp": formatTimestamp(e.timestamp),
+		"data":      data,
+	}
+}
+
+//--------------------------------------
+// Streaming
+//--------------------------------------
+
+// serveStream handles a chunked PATCH event stream. The client sends an
+// HTTP/1.0 request with a chunked body, which net/http does not decode, so
+// the connection is hijacked and the chunks are read directly. If name is
+// blank then each event must specify its own table.
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request, name string) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		writeError(w, &statusError{http.StatusInternalServerError, "Streaming not supported"})
+		return
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	err = s.readStream(rw.Reader, name)
+	if err == nil {
+		fmt.Fprintf(conn, "HTTP/1.0 200 OK\r\nContent-Type: application/json\r\nContent-Length: 2\r\n\r\n{}")
+		return
+	}
+
+	status, message := http.StatusInternalServerError, err.Error()
+	if err, ok := err.(*statusError); ok {
+		status, message = err.status, err.message
+	}
+	body, _ := json.Marshal(map[string]interface{}{"message": message})
+	fmt.Fprintf(conn, "HTTP/1.0 %d %s\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", status, http.StatusText(status), len(body), body)
+}
+
+func (s *Server) readStream(r *bufio.Reader, name string) error {
+	body := httputil.NewChunkedReader(r)
+	decoder := json.NewDecoder(body)
+	for {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err == io.EOF {
+			return nil
+		} else if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) || err == io.ErrUnexpectedEOF {
+				return err
+			}
+			return &statusError{http.StatusBadRequest, fmt.Sprintf("Malformed event: %v", err)}
+		}
+		if err := s.insertStreamEvent(obj, name); err != nil {
+			// Drain the rest of the request so the client can read the response.
+			io.Copy(io.Discard, body)
+			return err
+		}
+	}
+}
+
+func (s *Server) insertStreamEvent(obj map[string]interface{}, name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if name == "" {
+		name, _ = obj["table"].(string)
+	}
+	t := s.tables[name]
+	if t == nil {
+		return &statusError{http.StatusNotFound, fmt.Sprintf("Table does not exist: %s", name)}
+	}
+
+	str, _ := obj["timestamp"].(string)
+	timestamp, err := parseTimestamp(str)
+	if err != nil {
+		return &statusError{http.StatusBadRequest, fmt.Sprintf("Invalid timestamp: %v", obj["timestamp"])}
+	}
+	objectId, _ := obj["id"].(string)
+	data, _ := obj["data"].(map[string]interface{})
+	return t.insertEvent(objectId, &event{timestamp: timestamp, data: data}, true)
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && err != io.EOF {
+		return &statusError{http.StatusBadRequest, fmt.Sprintf("Malformed request body: %v", err)}
+	}
+	return nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status, message := http.StatusInternalServerError, err.Error()
+	if err, ok := err.(*statusError); ok {
+		status, message = err.status, err.message
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"message": message})
+}
+
+func parseTimestamp(str string) (time.Time, error) {
+	if timestamp, err := time.Parse(time.RFC3339Nano, str); err == nil {
+		return timestamp, nil
+	}
+	return time.Parse(time.RFC3339, str)
+}
+
+func formatTimestamp(timestamp time.Time) string {
+	return timestamp.UTC().Format(time.RFC3339Nano)
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+//------------------------------------------------------------------------------
+//
+// Sorting
+//
+//------------------------------------------------------------------------------
+
+type propertiesById []*property
+
+func (s propertiesById) Len() int           { return len(s) }
+func (s propertiesById) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s propertiesById) Less(i, j int) bool { return s[i].Id < s[j].Id }
+
+type eventsByTimestamp []*event
+
+func (s eventsByTimestamp) Len() int           { return len(s) }
+func (s eventsByTimestamp) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s eventsByTimestamp) Less(i, j int) bool { return s[i].timestamp.Before(s[j].timestamp) }
@@ -0,0 +1,432 @@
+package sky
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+//
+// Constants
+//
+//------------------------------------------------------------------------------
+
+// Spool fsync policies.
+const (
+	SyncAlways   = "always"
+	SyncInterval = "interval"
+	SyncNever    = "never"
+)
+
+const (
+	spoolSegmentExt = ".log"
+	spoolCursorFile = "cursor.json"
+
+	// The maximum number of bytes sent in each request while draining.
+	spoolBatchSize = 1 << 20
+)
+
+//------------------------------------------------------------------------------
+//
+// Errors
+//
+//------------------------------------------------------------------------------
+
+var (
+	// ErrSpoolFull is returned when an event would grow the spool beyond
+	// its maximum size.
+	ErrSpoolFull = errors.New("sky: spool full")
+
+	// ErrSpooled is returned when a stream is closed while events remain in
+	// its spool. The events are kept on disk and drained by the next stream
+	// that uses the spool.
+	ErrSpooled = errors.New("sky: events remain spooled")
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// A Spool is an on-disk, append-only log of events that a stream could not
+// deliver. Events are written to numbered segment files in a directory and
+// drained in order once the server is reachable again. The drain position is
+// saved after each batch is acknowledged so that it survives restarts.
+//
+// A spool directory must only be used by one stream at a time.
+type Spool struct {
+	config   SpoolConfig
+	segments []int64
+	file     *os.File
+	fileSize int64
+	size     int64
+	cursor   spoolCursor
+	lastSync time.Time
+}
+
+// SpoolConfig configures a spool. Zero values use the defaults.
+type SpoolConfig struct {
+	// The directory holding the segment files. It is created if needed.
+	Dir string
+
+	// The size at which a new segment file is started. Defaults to 64MB.
+	SegmentSize int64
+
+	// The maximum number of bytes waiting in the spool. Zero is unlimited.
+	MaxSize int64
+
+	// When spooled events are synced to disk: SyncAlways after every
+	// event, SyncInterval at most once per SyncInterval or SyncNever to
+	// leave it to the operating system. Defaults to SyncInterval.
+	Sync         string
+	SyncInterval time.Duration
+
+	// The minimum time between attempts to reach the server while events
+	// are spooled. Defaults to 5 seconds.
+	RetryInterval time.Duration
+}
+
+// spoolCursor is the position of the next event to drain.
+type spoolCursor struct {
+	Segment int64 `json:"segment"`
+	Offset  int64 `json:"offset"`
+}
+
+//------------------------------------------------------------------------------
+//
+// Constructor
+//
+//------------------------------------------------------------------------------
+
+// OpenSpool opens or creates a spool. Events left from a previous process
+// are drained from where that process stopped.
+func OpenSpool(config SpoolConfig) (*Spool, error) {
+	if config.Dir == "" {
+		return nil, errors.New("sky.Spool: Directory required")
+	}
+	switch config.Sync {
+	case "":
+		config.Sync = SyncInterval
+	case SyncAlways, SyncInterval, SyncNever:
+	default:
+		return nil, fmt.Errorf("sky.Spool: Invalid sync policy: %s", config.Sync)
+	}
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = 64 << 20
+	}
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = time.Second
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = 5 * time.Second
+	}
+	if err := os.MkdirAll(config.Dir, 0700); err != nil {
+		return nil, err
+	}
+
+	s := &Spool{config: config, lastSync: time.Now()}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+//
+// Methods
+//
+//------------------------------------------------------------------------------
+
+// Retrieves the number of bytes waiting to be drained.
+func (s *Spool) Size() int64 {
+	return s.size
+}
+
+// Returns true if there are no events waiting to be drained.
+func (s *Spool) Empty() bool {
+	return s.size == 0
+}
+
+// Syncs and closes the current segment file.
+func (s *Spool) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Sync()
+	if e := s.file.Close(); err == nil {
+		err = e
+	}
+	s.file = nil
+	return err
+}
+
+// load reads the segments and cursor from the directory. A partially
+// written event at the end of the last segment is truncated.
+func (s *Spool) load() error {
+	entries, err := os.ReadDir(s.config.Dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, spoolSegmentExt) {
+			continue
+		}
+		if id, err := strconv.ParseInt(strings.TrimSuffix(name, spoolSegmentExt), 10, 64); err == nil {
+			s.segments = append(s.segments, id)
+		}
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i] < s.segments[j] })
+
+	if b, err := os.ReadFile(filepath.Join(s.config.Dir, spoolCursorFile)); err == nil {
+		if err := json.Unmarshal(b, &s.cursor); err != nil {
+			return fmt.Errorf("sky.Spool: Invalid cursor: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	// Remove segments that were drained before the cursor was saved and
+	// start from the first remaining segment if the cursor's is gone.
+	for len(s.segments) > 0 && s.segments[0] < s.cursor.Segment {
+		if err := os.Remove(s.segmentPath(s.segments[0])); err != nil {
+			return err
+		}
+		s.segments = s.segments[1:]
+	}
+	if len(s.segments) > 0 && s.segments[0] != s.cursor.Segment {
+		s.cursor = spoolCursor{Segment: s.segments[0]}
+	}
+	if len(s.segments) == 0 {
+		return nil
+	}
+
+	// Reopen the last segment for appending.
+	last := s.segments[len(s.segments)-1]
+	f, err := os.OpenFile(s.segmentPath(last), os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if n := int64(bytes.LastIndexByte(data, '\n') + 1); n < int64(len(data)) {
+		if err := f.Truncate(n); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	size := int64(bytes.LastIndexByte(data, '\n') + 1)
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	s.file, s.fileSize = f, size
+
+	for _, id := range s.segments {
+		if id == last {
+			s.size += size
+		} else if fi, err := os.Stat(s.segmentPath(id)); err == nil {
+			s.size += fi.Size()
+		}
+	}
+	s.size -= s.cursor.Offset
+	if s.size < 0 {
+		s.size = 0
+	}
+	return nil
+}
+
+// append writes an encoded event to the end of the spool.
+func (s *Spool) append(data []byte) error {
+	if s.config.MaxSize > 0 && s.size+int64(len(data)) > s.config.MaxSize {
+		return ErrSpoolFull
+	}
+
+	// Start a new segment once the current one is full.
+	if s.file == nil || (s.fileSize > 0 && s.fileSize+int64(len(data)) > s.config.SegmentSize) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.file.Write(data); err != nil {
+		// Drop a partially written event so the segment stays readable.
+		s.file.Truncate(s.fileSize)
+		s.file.Seek(s.fileSize, io.SeekStart)
+		return err
+	}
+	s.fileSize += int64(len(data))
+	s.size += int64(len(data))
+
+	if s.config.Sync == SyncAlways || (s.config.Sync == SyncInterval && time.Since(s.lastSync) >= s.config.SyncInterval) {
+		s.lastSync = time.Now()
+		return s.file.Sync()
+	}
+	return nil
+}
+
+// rotate closes the current segment and starts the next one.
+func (s *Spool) rotate() error {
+	// Segment ids keep increasing after the spool is drained so that a saved
+	// cursor never points past a new segment.
+	id := s.cursor.Segment
+	if len(s.segments) > 0 {
+		id = s.segments[len(s.segments)-1] + 1
+	} else if id == 0 {
+		id = 1
+	}
+	if s.file != nil {
+		if err := s.Close(); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(s.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if len(s.segments) == 0 {
+		s.cursor = spoolCursor{Segment: id}
+	}
+	s.segments = append(s.segments, id)
+	s.file, s.fileSize = f, 0
+	return nil
+}
+
+// read returns whole events from the cursor, up to about max bytes, and the
+// cursor following them.
+func (s *Spool) read(max int) ([]byte, spoolCursor, error) {
+	if s.Empty() {
+		return nil, s.cursor, nil
+	}
+	f, err := os.Open(s.segmentPath(s.cursor.Segment))
+	if err != nil {
+		return nil, s.cursor, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(s.cursor.Offset, io.SeekStart); err != nil {
+		return nil, s.cursor, err
+	}
+
+	// Read up to max bytes, extending to the end of an event that is
+	// larger than max.
+	buf := make([]byte, max)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, s.cursor, err
+	}
+	data := buf[:n]
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[:i+1]
+	} else if n == max {
+		rest, err := readLine(f)
+		if err != nil {
+			return nil, s.cursor, err
+		}
+		data = append(data, rest...)
+	} else {
+		data = nil
+	}
+
+	// Move to the next segment once this one is exhausted.
+	next := spoolCursor{Segment: s.cursor.Segment, Offset: s.cursor.Offset + int64(len(data))}
+	if len(data) == 0 && len(s.segments) > 1 && s.segments[0] == s.cursor.Segment {
+		next = spoolCursor{Segment: s.segments[1]}
+	}
+	return data, next, nil
+}
+
+// advance moves the cursor once events have been delivered, saves it and
+// removes drained segments. A fully drained spool is reset.
+func (s *Spool) advance(next spoolCursor) error {
+	if next.Segment == s.cursor.Segment {
+		s.size -= next.Offset - s.cursor.Offset
+	}
+	s.cursor = next
+	for len(s.segments) > 1 && s.segments[0] < s.cursor.Segment {
+		if err := os.Remove(s.segmentPath(s.segments[0])); err != nil {
+			return err
+		}
+		s.segments = s.segments[1:]
+	}
+
+	if s.size <= 0 && len(s.segments) == 1 {
+		s.size = 0
+		if err := s.Close(); err != nil {
+			return err
+		}
+		if err := os.Remove(s.segmentPath(s.segments[0])); err != nil {
+			return err
+		}
+		s.cursor = spoolCursor{Segment: s.segments[0] + 1}
+		s.segments = nil
+	}
+	return s.saveCursor()
+}
+
+// saveCursor atomically replaces the cursor file.
+func (s *Spool) saveCursor() error {
+	b, err := json.Marshal(s.cursor)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.config.Dir, spoolCursorFile)
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if s.config.Sync != SyncNever {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func (s *Spool) segmentPath(id int64) string {
+	return filepath.Join(s.config.Dir, fmt.Sprintf("%020d%s", id, spoolSegmentExt))
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// readLine reads up to and including the next newline.
+func readLine(r io.Reader) ([]byte, error) {
+	var line []byte
+	b := make([]byte, 4096)
+	for {
+		n, err := r.Read(b)
+		if i := bytes.IndexByte(b[:n], '\n'); i >= 0 {
+			return append(line, b[:i+1]...), nil
+		}
+		line = append(line, b[:n]...)
+		if err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package sky
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+// Ensure that spooled events are read back in order across segments and that
+// the drain position survives reopening the spool.
+func TestSpoolReadAdvance(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := OpenSpool(SpoolConfig{Dir: dir, SegmentSize: 8, MaxSize: 20, Sync: SyncAlways})
+	if err != nil {
+		t.Fatalf("Unable to open spool: %v", err)
+	}
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n"} {
+		if err := spool.append([]byte(line)); err != nil {
+			t.Fatalf("Unable to append: %v", err)
+		}
+	}
+	if err := spool.append([]byte("dddddd\n")); !errors.Is(err, ErrSpoolFull) {
+		t.Fatalf("Expected full spool: %v", err)
+	}
+	if spool.Size() != 15 || len(spool.segments) != 3 {
+		t.Fatalf("Unexpected spool: %d bytes, %d segments", spool.Size(), len(spool.segments))
+	}
+
+	data, next, err := spool.read(1024)
+	if err != nil || string(data) != "aaaa\n" {
+		t.Fatalf("Unexpected read: %q (%v)", data, err)
+	}
+	if err := spool.advance(next); err != nil {
+		t.Fatalf("Unable to advance: %v", err)
+	}
+	spool.Close()
+
+	// Write a partial event to simulate a crash during an append.
+	f, _ := os.OpenFile(spool.segmentPath(3), os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString("ee")
+	f.Close()
+
+	spool, err = OpenSpool(SpoolConfig{Dir: dir, SegmentSize: 8})
+	if err != nil {
+		t.Fatalf("Unable to reopen spool: %v", err)
+	}
+	defer spool.Close()
+	var lines string
+	for !spool.Empty() {
+		data, next, err := spool.read(1024)
+		if err != nil {
+			t.Fatalf("Unable to read: %v", err)
+		}
+		lines += string(data)
+		if err := spool.advance(next); err != nil {
+			t.Fatalf("Unable to advance: %v", err)
+		}
+	}
+	if lines != "bbbb\ncccc\n" {
+		t.Fatalf("Unexpected events: %q", lines)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.log")); len(matches) != 0 {
+		t.Fatalf("Expected drained segments to be removed: %v", matches)
+	}
+
+	// New segments continue after the drained ones.
+	if err := spool.append([]byte("ffff\n")); err != nil || spool.segments[0] != 4 {
+		t.Fatalf("Unexpected segments: %v (%v)", spool.segments, err)
+	}
+}
+
+// Ensure that a stream spools events while the server is unreachable and
+// drains them in order once it is back, including from a new process.
+func TestStreamSpool(t *testing.T) {
+	var down atomic.Bool
+	server := skytest.NewUnstartedServer()
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+
+	client := NewClientEx(server.Host(), server.Port())
+	table := NewTable(testTableName, nil)
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to setup test table: %v", err)
+	}
+
+	dir := t.TempDir()
+	spool, err := OpenSpool(SpoolConfig{Dir: dir, RetryInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Unable to open spool: %v", err)
+	}
+	stream, err := table.Stream()
+	if err != nil {
+		t.Fatalf("Failed to create event stream: (%v)", err)
+	}
+	stream.SetSpool(spool)
+
+	down.Store(true)
+	stream.conn.Close()
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		if err := stream.AddEvent("xyz", NewEvent(now.Add(time.Duration(i)*time.Hour), nil)); err != nil {
+			t.Fatalf("Failed to add event #%d: (%v)", i, err)
+		}
+	}
+	if err := stream.Commit(); err != nil {
+		t.Fatalf("Commit while spooling failed: (%v)", err)
+	}
+	if err := stream.Close(); !errors.Is(err, ErrSpooled) {
+		t.Fatalf("Expected spooled events on close: (%v)", err)
+	}
+	spool.Close()
+
+	down.Store(false)
+	spool, err = OpenSpool(SpoolConfig{Dir: dir})
+	if err != nil || spool.Empty() {
+		t.Fatalf("Expected spooled events after reopening: %d bytes (%v)", spool.Size(), err)
+	}
+	defer spool.Close()
+	stream, err = table.Stream()
+	if err != nil {
+		t.Fatalf("Failed to create event stream: (%v)", err)
+	}
+	stream.SetSpool(spool)
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Closing stream failed: (%v)", err)
+	}
+	if !spool.Empty() {
+		t.Fatalf("Expected drained spool: %d bytes", spool.Size())
+	}
+	events, err := table.GetEvents("xyz")
+	if err != nil || len(events) != 10 {
+		t.Fatalf("Failed to get 10 events back: %d events, (%v)", len(events), err)
+	}
+}
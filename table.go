@@ -1,6 +1,7 @@
 package sky
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +14,9 @@ const (
 )
 
 // A Table is a container for objects and events.
+//
+// Each server operation has a Context variant which uses the context for
+// cancellation, deadlines and request-scoped values.
 type Table interface {
 	// Retrieves the name of the table.
 	Name() string
@@ -25,47 +29,85 @@ type Table interface {
 
 	// Retrieves a single property from the server.
 	GetProperty(name string) (*Property, error)
+	GetPropertyContext(ctx context.Context, name string) (*Property, error)
 
 	// Retrieves a list of all properties on the table.
 	GetProperties() ([]*Property, error)
+	GetPropertiesContext(ctx context.Context) ([]*Property, error)
 
 	// Creates a property on the table.
 	CreateProperty(property *Property) error
+	CreatePropertyContext(ctx context.Context, property *Property) error
 
 	// Updates a property on the table.
 	UpdateProperty(name string, property *Property) error
+	UpdatePropertyContext(ctx context.Context, name string, property *Property) error
 
 	// Deletes a property on the table.
 	DeleteProperty(property *Property) error
+	DeletePropertyContext(ctx context.Context, property *Property) error
 
 	// Retrieves a single event for an object.
 	GetEvent(objectId string, timestamp time.Time) (*Event, error)
+	GetEventContext(ctx context.Context, objectId string, timestamp time.Time) (*Event, error)
 
 	// Retrieves a list of all events for an object.
 	GetEvents(objectId string) ([]*Event, error)
+	GetEventsContext(ctx context.Context, objectId string) ([]*Event, error)
+
+	// Retrieves an iterator over an object's events within optional time
+	// bounds, limit and order.
+	Events(objectId string, options *EventOptions) (*EventIterator, error)
+	EventsContext(ctx context.Context, objectId string, options *EventOptions) (*EventIterator, error)
+
+	// Retrieves the events of many objects concurrently, keyed by object id.
+	GetEventsBatch(ids []string) (map[string][]*Event, error)
+	GetEventsBatchContext(ctx context.Context, ids []string, options *BatchOptions) (map[string][]*Event, error)
+
+	// Fetches the events of many objects concurrently and sends each result
+	// as it completes.
+	StreamEventsBatch(ctx context.Context, ids []string, options *BatchOptions) <-chan *ObjectEvents
 
 	// Adds an event to an object.
 	AddEvent(objectId string, event *Event, method string) error
+	AddEventContext(ctx context.Context, objectId string, event *Event, method string) error
 
 	// Deletes an event on the table.
 	DeleteEvent(objectId string, event *Event) error
+	DeleteEventContext(ctx context.Context, objectId string, event *Event) error
 
 	// Deletes all object events on the table.
 	DeleteEvents(objectId string) error
+	DeleteEventsContext(ctx context.Context, objectId string) error
+
+	// Retrieves an iterator over the ids of the table's objects.
+	Objects() (*ObjectIterator, error)
+	ObjectsContext(ctx context.Context) (*ObjectIterator, error)
+
+	// Checks whether an object has any events.
+	ObjectExists(objectId string) (bool, error)
+	ObjectExistsContext(ctx context.Context, objectId string) (bool, error)
+
+	// Retrieves a summary of an object's events.
+	GetObject(objectId string) (*ObjectSummary, error)
+	GetObjectContext(ctx context.Context, objectId string) (*ObjectSummary, error)
 
 	// Opens a table specific event stream to the server.
 	Stream() (*TableEventStream, error)
+	StreamContext(ctx context.Context) (*TableEventStream, error)
 
 	// Retrieves basic stats on the table.
 	Stats() (*Stats, error)
+	StatsContext(ctx context.Context) (*Stats, error)
 
 	// Executes a raw query on the table.
 	RawQuery(q map[string]interface{}) (map[string]interface{}, error)
+	RawQueryContext(ctx context.Context, q map[string]interface{}) (map[string]interface{}, error)
 }
 
 type table struct {
 	client Client
-	name   string `json:"name"`
+	name   string
 }
 
 // Creates a new table attached to a given client.
@@ -93,6 +135,11 @@ func (t *table) SetClient(c Client) {
 
 // Retrieves a single property from the server.
 func (t *table) GetProperty(name string) (*Property, error) {
+	return t.GetPropertyContext(context.Background(), name)
+}
+
+// Retrieves a single property from the server using the given context.
+func (t *table) GetPropertyContext(ctx context.Context, name string) (*Property, error) {
 	if t.client == nil {
 		return nil, errors.New("Table is not attached to a client")
 	}
@@ -100,7 +147,7 @@ func (t *table) GetProperty(name string) (*Property, error) {
 		return nil, errors.New("Property name required")
 	}
 	property := &Property{}
-	if err := t.client.Send("GET", fmt.Sprintf("/tables/%s/properties/%s", t.name, name), nil, property); err != nil {
+	if err := t.client.SendContext(ctx, "GET", fmt.Sprintf("/tables/%s/properties/%s", t.name, name), nil, property); err != nil {
 		return nil, err
 	}
 	return property, nil
@@ -108,11 +155,16 @@ func (t *table) GetProperty(name string) (*Property, error) {
 
 // Retrieves a list of all properties on the table.
 func (t *table) GetProperties() ([]*Property, error) {
+	return t.GetPropertiesContext(context.Background())
+}
+
+// Retrieves a list of all properties on the table using the given context.
+func (t *table) GetPropertiesContext(ctx context.Context) ([]*Property, error) {
 	if t.client == nil {
 		return nil, errors.New("Table is not attached to a client")
 	}
 	properties := []*Property{}
-	if err := t.client.Send("GET", fmt.Sprintf("/tables/%s/properties", t.name), nil, &properties); err != nil {
+	if err := t.client.SendContext(ctx, "GET", fmt.Sprintf("/tables/%s/properties", t.name), nil, &properties); err != nil {
 		return nil, err
 	}
 	return properties, nil
@@ -120,17 +172,27 @@ func (t *table) GetProperties() ([]*Property, error) {
 
 // Creates a property on the table.
 func (t *table) CreateProperty(property *Property) error {
+	return t.CreatePropertyContext(context.Background(), property)
+}
+
+// Creates a property on the table using the given context.
+func (t *table) CreatePropertyContext(ctx context.Context, property *Property) error {
 	if t.client == nil {
 		return errors.New("Table is not attached to a client")
 	}
 	if property == nil {
 		return errors.New("Property required")
 	}
-	return t.client.Send("POST", fmt.Sprintf("/tables/%s/properties", t.name), property, property)
+	return t.client.SendContext(ctx, "POST", fmt.Sprintf("/tables/%s/properties", t.name), property, property)
 }
 
 // Updates a property on the table.
 func (t *table) UpdateProperty(name string, property *Property) error {
+	return t.UpdatePropertyContext(context.Background(), name, property)
+}
+
+// Updates a property on the table using the given context.
+func (t *table) UpdatePropertyContext(ctx context.Context, name string, property *Property) error {
 	if t.client == nil {
 		return errors.New("Table is not attached to a client")
 	}
@@ -140,22 +202,32 @@ func (t *table) UpdateProperty(name string, property *Property) error {
 	if property == nil {
 		return errors.New("Property required")
 	}
-	return t.client.Send("PATCH", fmt.Sprintf("/tables/%s/properties/%s", t.name, name), property, property)
+	return t.client.SendContext(ctx, "PATCH", fmt.Sprintf("/tables/%s/properties/%s", t.name, name), property, property)
 }
 
 // Deletes a property on the table.
 func (t *table) DeleteProperty(property *Property) error {
+	return t.DeletePropertyContext(context.Background(), property)
+}
+
+// Deletes a property on the table using the given context.
+func (t *table) DeletePropertyContext(ctx context.Context, property *Property) error {
 	if t.client == nil {
 		return errors.New("Table is not attached to a client")
 	}
 	if property == nil {
 		return errors.New("Property required")
 	}
-	return t.client.Send("DELETE", fmt.Sprintf("/tables/%s/properties/%s", t.name, property.Name), nil, nil)
+	return t.client.SendContext(ctx, "DELETE", fmt.Sprintf("/tables/%s/properties/%s", t.name, property.Name), nil, nil)
 }
 
 // Retrieves a single event for an object.
 func (t *table) GetEvent(objectId string, timestamp time.Time) (*Event, error) {
+	return t.GetEventContext(context.Background(), objectId, timestamp)
+}
+
+// Retrieves a single event for an object using the given context.
+func (t *table) GetEventContext(ctx context.Context, objectId string, timestamp time.Time) (*Event, error) {
 	if t.client == nil {
 		return nil, errors.New("Table is not attached to a client")
 	}
@@ -164,7 +236,7 @@ func (t *table) GetEvent(objectId string, timestamp time.Time) (*Event, error) {
 	}
 
 	e := map[string]interface{}{}
-	if err := t.client.Send("GET", fmt.Sprintf("/tables/%s/objects/%s/events/%s", t.name, objectId, FormatTimestamp(timestamp)), nil, &e); err != nil {
+	if err := t.client.SendContext(ctx, "GET", fmt.Sprintf("/tables/%s/objects/%s/events/%s", t.name, objectId, FormatTimestamp(timestamp)), nil, &e); err != nil {
 		return nil, err
 	}
 
@@ -178,6 +250,11 @@ func (t *table) GetEvent(objectId string, timestamp time.Time) (*Event, error) {
 
 // Retrieves a list of all events for an object.
 func (t *table) GetEvents(objectId string) ([]*Event, error) {
+	return t.GetEventsContext(context.Background(), objectId)
+}
+
+// Retrieves a list of all events for an object using the given context.
+func (t *table) GetEventsContext(ctx context.Context, objectId string) ([]*Event, error) {
 	if t.client == nil {
 		return nil, errors.New("Table is not attached to a client")
 	}
@@ -185,7 +262,7 @@ func (t *table) GetEvents(objectId string) ([]*Event, error) {
 		return nil, errors.New("Object identifier required")
 	}
 	output := make([]map[string]interface{}, 0)
-	if err := t.client.Send("GET", fmt.Sprintf("/tables/%s/objects/%s/events", t.name, objectId), nil, &output); err != nil {
+	if err := t.client.SendContext(ctx, "GET", fmt.Sprintf("/tables/%s/objects/%s/events", t.name, objectId), nil, &output); err != nil {
 		return nil, err
 	}
 
@@ -201,6 +278,11 @@ func (t *table) GetEvents(objectId string) ([]*Event, error) {
 
 // Adds an event to an object.
 func (t *table) AddEvent(objectId string, event *Event, method string) error {
+	return t.AddEventContext(context.Background(), objectId, event, method)
+}
+
+// Adds an event to an object using the given context.
+func (t *table) AddEventContext(ctx context.Context, objectId string, event *Event, method string) error {
 	if objectId == "" {
 		return errors.New("Object identifier required")
 	}
@@ -215,11 +297,16 @@ func (t *table) AddEvent(objectId string, event *Event, method string) error {
 	}
 
 	// Serialize data and send to server.
-	return t.client.Send(httpMethod, fmt.Sprintf("/tables/%s/objects/%s/events/%s", t.name, objectId, FormatTimestamp(event.Timestamp)), event.Serialize(), nil)
+	return t.client.SendContext(ctx, httpMethod, fmt.Sprintf("/tables/%s/objects/%s/events/%s", t.name, objectId, FormatTimestamp(event.Timestamp)), event.Serialize(), nil)
 }
 
 // Deletes an event on the table.
 func (t *table) DeleteEvent(objectId string, event *Event) error {
+	return t.DeleteEventContext(context.Background(), objectId, event)
+}
+
+// Deletes an event on the table using the given context.
+func (t *table) DeleteEventContext(ctx context.Context, objectId string, event *Event) error {
 	if t.client == nil {
 		return errors.New("Table is not attached to a client")
 	}
@@ -229,22 +316,31 @@ func (t *table) DeleteEvent(objectId string, event *Event) error {
 	if event == nil {
 		return errors.New("Event required")
 	}
-	return t.client.Send("DELETE", fmt.Sprintf("/tables/%s/objects/%s/events/%s", t.name, objectId, FormatTimestamp(event.Timestamp)), nil, nil)
+	return t.client.SendContext(ctx, "DELETE", fmt.Sprintf("/tables/%s/objects/%s/events/%s", t.name, objectId, FormatTimestamp(event.Timestamp)), nil, nil)
 }
 
 // Deletes all object events on the table.
 func (t *table) DeleteEvents(objectId string) error {
+	return t.DeleteEventsContext(context.Background(), objectId)
+}
+
+// Deletes all object events on the table using the given context.
+func (t *table) DeleteEventsContext(ctx context.Context, objectId string) error {
 	if t.client == nil {
 		return errors.New("Table is not attached to a client")
 	}
 	if objectId == "" {
 		return errors.New("Object identifier required")
 	}
-	return t.client.Send("DELETE", fmt.Sprintf("/tables/%s/objects/%s/events", t.name, objectId), nil, nil)
+	return t.client.SendContext(ctx, "DELETE", fmt.Sprintf("/tables/%s/objects/%s/events", t.name, objectId), nil, nil)
 }
 
 func (t *table) Stream() (*TableEventStream, error) {
-	return NewTableEventStream(t.client, t)
+	return t.StreamContext(context.Background())
+}
+
+func (t *table) StreamContext(ctx context.Context) (*TableEventStream, error) {
+	return NewTableEventStreamContext(ctx, t.client, t)
 }
 
 // Determines the appropriate HTTP method to use given an insertion method (Replace, Merge).
@@ -260,11 +356,16 @@ func getInsertHttpMethod(method string) (string, error) {
 
 // Retrieves basic stats on the table.
 func (t *table) Stats() (*Stats, error) {
+	return t.StatsContext(context.Background())
+}
+
+// Retrieves basic stats on the table using the given context.
+func (t *table) StatsContext(ctx context.Context) (*Stats, error) {
 	if t.client == nil {
 		return nil, errors.New("Table is not attached to a client")
 	}
 	output := &Stats{}
-	if err := t.client.Send("GET", fmt.Sprintf("/tables/%s/stats", t.name), nil, &output); err != nil {
+	if err := t.client.SendContext(ctx, "GET", fmt.Sprintf("/tables/%s/stats", t.name), nil, &output); err != nil {
 		return nil, err
 	}
 	return output, nil
@@ -272,6 +373,11 @@ func (t *table) Stats() (*Stats, error) {
 
 // Executes a raw query on the table.
 func (t *table) RawQuery(q map[string]interface{}) (map[string]interface{}, error) {
+	return t.RawQueryContext(context.Background(), q)
+}
+
+// Executes a raw query on the table using the given context.
+func (t *table) RawQueryContext(ctx context.Context, q map[string]interface{}) (map[string]interface{}, error) {
 	if t.client == nil {
 		return nil, errors.New("Table is not attached to a client")
 	}
@@ -279,7 +385,7 @@ func (t *table) RawQuery(q map[string]interface{}) (map[string]interface{}, erro
 		return nil, errors.New("Query required")
 	}
 	output := map[string]interface{}{}
-	if err := t.client.Send("POST", fmt.Sprintf("/tables/%s/query", t.name), q, &output); err != nil {
+	if err := t.client.SendContext(ctx, "POST", fmt.Sprintf("/tables/%s/query", t.name), q, &output); err != nil {
 		return nil, err
 	}
 	return output, nil
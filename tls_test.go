@@ -0,0 +1,52 @@
+package sky
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/snormore/gosky/skytest"
+)
+
+// Ensure that requests and streams work against a TLS server.
+func TestTLS(t *testing.T) {
+	server := skytest.NewUnstartedServer()
+	server.StartTLS()
+	defer server.Close()
+
+	client := NewClientEx(server.Host(), server.Port())
+	client.SetScheme("https")
+
+	// The server's certificate is not trusted by default.
+	if client.Ping() {
+		t.Fatalf("Expected untrusted certificate to fail")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	client.SetTLSConfig(&tls.Config{RootCAs: pool})
+
+	table := NewTable(testTableName, nil)
+	if err := client.CreateTable(table); err != nil {
+		t.Fatalf("Unable to create table: %v", err)
+	}
+
+	stream, err := table.Stream()
+	if err != nil {
+		t.Fatalf("Failed to create event stream: (%v)", err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := stream.AddEvent("xyz", NewEvent(time.Unix(int64(i), 0), nil)); err != nil {
+			t.Fatalf("Failed to add event #%d: (%v)", i, err)
+		}
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Closing stream failed: (%v)", err)
+	}
+
+	events, err := table.GetEvents("xyz")
+	if err != nil || len(events) != 10 {
+		t.Fatalf("Failed to get 10 events back: %d events, (%v)", len(events), err)
+	}
+}
@@ -0,0 +1,79 @@
+package sky
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+//
+// Typedefs
+//
+//------------------------------------------------------------------------------
+
+// A Tracer creates spans around requests and stream operations and
+// propagates trace context to the server. The skyotel package provides an
+// OpenTelemetry implementation.
+type Tracer interface {
+	// Starts a span with the given attributes. The returned context carries
+	// the span and the returned function ends it with the operation's error.
+	Start(ctx context.Context, name string, attrs map[string]interface{}) (context.Context, func(error))
+
+	// Adds the trace context headers for the span in ctx to a request.
+	Inject(ctx context.Context, header http.Header)
+}
+
+//------------------------------------------------------------------------------
+//
+// Functions
+//
+//------------------------------------------------------------------------------
+
+// startSpan starts a span if the client has a tracer.
+func startSpan(ctx context.Context, c Client, name string, attrs map[string]interface{}) (context.Context, func(error)) {
+	tracer := c.Tracer()
+	if tracer == nil {
+		return ctx, func(error) {}
+	}
+	return tracer.Start(ctx, name, attrs)
+}
+
+// injectTrace adds trace context headers to a request if the client has a
+// tracer.
+func injectTrace(ctx context.Context, c Client, header http.Header) {
+	if tracer := c.Tracer(); tracer != nil {
+		tracer.Inject(ctx, header)
+	}
+}
+
+// requestAttributes returns the span attributes for a request.
+func requestAttributes(method string, path string, body []byte) map[string]interface{} {
+	path, _, _ = strings.Cut(path, "?")
+	attrs := map[string]interface{}{
+		"http.request.method": method,
+		"url.path":            path,
+		"sky.endpoint":        Endpoint(path),
+	}
+	if table := tableName(path); table != "" {
+		attrs["sky.table"] = table
+	}
+	if segments := strings.Split(strings.Trim(path, "/"), "/"); len(segments) > 3 && segments[2] == "objects" {
+		attrs["sky.object_ids"] = 1
+	}
+	if len(body) > 0 {
+		attrs["sky.request.size"] = len(body)
+		if strings.HasSuffix(path, "/query") {
+			attrs["sky.query.size"] = len(body)
+		}
+	}
+	return attrs
+}
+
+// tableName returns the table name in a request path, if there is one.
+func tableName(path string) string {
+	if segments := strings.Split(strings.Trim(path, "/"), "/"); len(segments) > 1 && segments[0] == "tables" {
+		return segments[1]
+	}
+	return ""
+}